@@ -0,0 +1,78 @@
+// Package cache stores the output of extension commands, to display them instantly the next time they are run.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pomdtr/sunbeam/internal/utils"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+var cacheDir = filepath.Join(utils.CacheDir(), "commands")
+
+// Key identifies the output of a command, run by the extension with the given payload
+func Key(entrypoint string, payload sunbeam.Payload) string {
+	bts, _ := json.Marshal(map[string]any{
+		"entrypoint": entrypoint,
+		"payload":    payload,
+	})
+
+	hash := sha256.Sum256(bts)
+	return hex.EncodeToString(hash[:])
+}
+
+// Get returns the cached output, and the time it was stored at
+func Get(key string) ([]byte, time.Time, bool) {
+	cachePath := filepath.Join(cacheDir, key)
+	info, err := os.Stat(cachePath)
+	if err != nil {
+		return nil, time.Time{}, false
+	}
+
+	bts, err := os.ReadFile(cachePath)
+	if err != nil {
+		return nil, time.Time{}, false
+	}
+
+	return bts, info.ModTime(), true
+}
+
+func Set(key string, output []byte) error {
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return err
+	}
+
+	// write to a temporary file first, so that a concurrent read never sees a partial output
+	f, err := os.CreateTemp(cacheDir, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(output); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	return os.Rename(f.Name(), filepath.Join(cacheDir, key))
+}
+
+// Clear removes all the cached outputs
+func Clear() error {
+	if err := os.RemoveAll(cacheDir); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/pomdtr/sunbeam/internal/cache"
+	"github.com/spf13/cobra"
+)
+
+func NewCmdCache() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "cache",
+		Short:   "Manage the cached outputs of commands",
+		GroupID: CommandGroupCore,
+	}
+
+	cmd.AddCommand(NewCmdCacheClear())
+	return cmd
+}
+
+func NewCmdCacheClear() *cobra.Command {
+	return &cobra.Command{
+		Use:   "clear",
+		Short: "Remove the cached outputs of all commands",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := cache.Clear(); err != nil {
+				return err
+			}
+
+			fmt.Println("✅ Cache cleared!")
+			return nil
+		},
+	}
+}
@@ -2,11 +2,14 @@ package cli
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"slices"
 	"sort"
+	"strings"
 
 	"github.com/mattn/go-isatty"
 	"github.com/pomdtr/sunbeam/internal/config"
@@ -70,10 +73,14 @@ func NewCmdCustom(alias string, extension extensions.Extension, extensionConfig
 				return err
 			}
 			if input.Preferences == nil {
-				input.Preferences = extensionConfig.Preferences
+				preferences, err := tui.ResolvePreferences(alias, extension, extensionConfig)
+				if err != nil {
+					return err
+				}
+				input.Preferences = preferences
 			}
 
-			return runExtension(extension, input)
+			return runExtension(alias, extension, input)
 		},
 	}
 
@@ -124,23 +131,24 @@ func NewSubCmdCustom(alias string, extension extensions.Extension, extensionConf
 						return err
 					}
 					params[param.Name] = value
-				}
-			}
+				case sunbeam.InputSelect:
+					value, err := cmd.Flags().GetString(param.Name)
+					if err != nil {
+						return err
+					}
 
-			preferences := extensionConfig.Preferences
-			if preferences == nil {
-				preferences = make(map[string]any)
+					if len(param.Options) > 0 && !slices.Contains(param.Options, value) {
+						return fmt.Errorf("invalid value for --%s: %s is not one of %s", param.Name, value, strings.Join(param.Options, ", "))
+					}
+					params[param.Name] = value
+				}
 			}
 
-			envs, err := tui.ExtractPreferencesFromEnv(alias, extension)
+			preferences, err := tui.ResolvePreferences(alias, extension, extensionConfig)
 			if err != nil {
 				return err
 			}
 
-			for name, value := range envs {
-				preferences[name] = value
-			}
-
 			input := sunbeam.Payload{
 				Command:     command.Name,
 				Preferences: preferences,
@@ -156,7 +164,7 @@ func NewSubCmdCustom(alias string, extension extensions.Extension, extensionConf
 				input.Query = string(bytes.Trim(stdin, "\n"))
 			}
 
-			return runExtension(extension, input)
+			return runExtension(alias, extension, input)
 		},
 	}
 
@@ -168,6 +176,18 @@ func NewSubCmdCustom(alias string, extension extensions.Extension, extensionConf
 			cmd.Flags().Bool(input.Name, false, input.Title)
 		case sunbeam.InputNumber:
 			cmd.Flags().Int(input.Name, 0, input.Title)
+		case sunbeam.InputSelect:
+			if input.OptionsCommand != "" {
+				cmd.Flags().String(input.Name, "", input.Title)
+				_ = cmd.RegisterFlagCompletionFunc(input.Name, completeOptions(alias, extension, extensionConfig, command, input))
+				break
+			}
+
+			cmd.Flags().String(input.Name, "", fmt.Sprintf("%s (%s)", input.Title, strings.Join(input.Options, ", ")))
+			options := input.Options
+			_ = cmd.RegisterFlagCompletionFunc(input.Name, func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+				return options, cobra.ShellCompDirectiveNoFileComp
+			})
 		}
 
 		if !input.Optional {
@@ -178,14 +198,98 @@ func NewSubCmdCustom(alias string, extension extensions.Extension, extensionConf
 	return cmd
 }
 
-func runExtension(extension extensions.Extension, input sunbeam.Payload) error {
+// savePreferences stores the secret preferences of the extension in the keyring, and the other ones in the config file
+func savePreferences(alias string, extension extensions.Extension, preferences map[string]any) error {
+	cfg, err := config.Load(config.Path)
+	if err != nil {
+		return err
+	}
+
+	extensionConfig, ok := cfg.Extensions[alias]
+	if !ok {
+		return fmt.Errorf("extension %s not found", alias)
+	}
+
+	if err := tui.StoreSecretPreferences(alias, extension, preferences); err != nil {
+		return err
+	}
+
+	if extensionConfig.Preferences == nil {
+		extensionConfig.Preferences = make(map[string]any)
+	}
+
+	for k, v := range preferences {
+		extensionConfig.Preferences[k] = v
+	}
+	cfg.Extensions[alias] = extensionConfig
+	return cfg.Save()
+}
+
+// completeOptions calls the options command of the input to complete the flag value.
+// The flags already set on the command line are forwarded as params.
+func completeOptions(alias string, extension extensions.Extension, extensionConfig config.ExtensionConfig, command sunbeam.CommandSpec, input sunbeam.Input) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		preferences, err := tui.ResolvePreferences(alias, extension, extensionConfig)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+
+		params := make(map[string]any)
+		for _, param := range command.Params {
+			if !cmd.Flags().Changed(param.Name) {
+				continue
+			}
+
+			switch param.Type {
+			case sunbeam.InputBoolean:
+				if value, err := cmd.Flags().GetBool(param.Name); err == nil {
+					params[param.Name] = value
+				}
+			case sunbeam.InputNumber:
+				if value, err := cmd.Flags().GetInt(param.Name); err == nil {
+					params[param.Name] = value
+				}
+			default:
+				if value, err := cmd.Flags().GetString(param.Name); err == nil {
+					params[param.Name] = value
+				}
+			}
+		}
+
+		items, err := extension.Options(input, preferences, params)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+
+		var completions []string
+		for _, item := range items {
+			value := item.Id
+			if value == "" {
+				value = item.Title
+			}
+
+			completions = append(completions, fmt.Sprintf("%s\t%s", value, item.Title))
+		}
+
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+func runExtension(alias string, extension extensions.Extension, input sunbeam.Payload) error {
 	command, ok := extension.Command(input.Command)
 	if !ok {
 		return fmt.Errorf("command %s not found", input.Command)
 	}
 
 	if !isatty.IsTerminal(os.Stdout.Fd()) {
-		cmd, err := extension.Cmd(input)
+		ctx := context.Background()
+		if timeout := extension.Timeout(command); timeout > 0 && command.Mode != sunbeam.CommandModeTTY {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		cmd, err := extension.CmdContext(ctx, input)
 		if err != nil {
 			return err
 		}
@@ -194,12 +298,23 @@ func runExtension(extension extensions.Extension, input sunbeam.Payload) error {
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
 
-		return cmd.Run()
+		if err := cmd.Run(); err != nil {
+			if ctx.Err() != nil {
+				return &extensions.CommandError{Err: ctx.Err()}
+			}
+
+			return err
+		}
+
+		return nil
 	}
 
 	switch command.Mode {
-	case sunbeam.CommandModeSearch, sunbeam.CommandModeFilter, sunbeam.CommandModeDetail:
+	case sunbeam.CommandModeSearch, sunbeam.CommandModeFilter, sunbeam.CommandModeDetail, sunbeam.CommandModeGrid:
 		runner := tui.NewRunner(extension, input)
+		runner.SavePreferences = func(preferences map[string]any) error {
+			return savePreferences(alias, extension, preferences)
+		}
 		return tui.Draw(runner)
 	case sunbeam.CommandModeSilent:
 		return extension.Run(input)
@@ -1,19 +1,25 @@
 package cli
 
 import (
+	"bufio"
 	_ "embed"
+	"errors"
 	"fmt"
+	"io"
 	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/cli/go-gh/v2/pkg/tableprinter"
 	"github.com/mattn/go-isatty"
 	"github.com/pomdtr/sunbeam/internal/config"
 	"github.com/pomdtr/sunbeam/internal/extensions"
+	"github.com/pomdtr/sunbeam/internal/secrets"
 	"github.com/pomdtr/sunbeam/internal/tui"
 	"github.com/pomdtr/sunbeam/pkg/sunbeam"
 	"github.com/spf13/cobra"
@@ -182,6 +188,7 @@ func NewCmdExtensionEdit(cfg config.Config) *cobra.Command {
 func NewCmdExtensionInstall(cfg config.Config) *cobra.Command {
 	var flags struct {
 		Alias string
+		Yes   bool
 	}
 
 	cmd := &cobra.Command{
@@ -206,12 +213,61 @@ func NewCmdExtensionInstall(cfg config.Config) *cobra.Command {
 				alias = a
 			}
 
-			if _, err := extensions.LoadExtension(origin); err != nil {
+			if _, ok := cfg.Extensions[alias]; ok {
+				return fmt.Errorf("extension %s already exists", alias)
+			}
+
+			// the extension is not trusted yet, its manifest is inspected in the sandbox
+			var update *extensions.Update
+			var manifest sunbeam.Manifest
+			var manifestErr error
+			if extensions.IsRemote(origin) {
+				update, err = extensions.Stage(config.ExtensionConfig{Origin: origin})
+				if err != nil {
+					return fmt.Errorf("failed to download extension: %w", err)
+				}
+
+				manifest, manifestErr = update.NewManifest, update.ManifestErr
+			} else {
+				manifest, manifestErr = extensions.Inspect(cfg.Resolve(origin))
+			}
+
+			if !flags.Yes {
+				if manifestErr != nil {
+					cmd.Printf("⚠️  The manifest could not be inspected in a sandbox (%s), its permissions are unknown.\n", manifestErr)
+				} else {
+					printPermissions(cmd, manifest.Permissions)
+				}
+
+				confirmed, err := confirm(cmd, fmt.Sprintf("Install %s?", alias))
+				if err != nil || !confirmed {
+					if update != nil {
+						_ = update.Discard()
+					}
+
+					if err != nil {
+						return err
+					}
+
+					cmd.Printf("Skipped %s\n", alias)
+					return nil
+				}
+			}
+
+			// the lock entry is only written once the extension is approved
+			if update != nil {
+				if err := update.Apply(); err != nil {
+					return fmt.Errorf("failed to install extension: %w", err)
+				}
+			}
+
+			extension, err := extensions.LoadExtension(origin)
+			if err != nil {
 				return fmt.Errorf("failed to load extension: %w", err)
 			}
 
-			if _, ok := cfg.Extensions[alias]; ok {
-				return fmt.Errorf("extension %s already exists", alias)
+			for _, limitation := range extension.SandboxLimitations() {
+				cmd.PrintErrf("⚠️  The permissions of %s are not fully enforced: %s.\n", alias, limitation)
 			}
 
 			cfg.Extensions[alias] = config.ExtensionConfig{
@@ -228,6 +284,7 @@ func NewCmdExtensionInstall(cfg config.Config) *cobra.Command {
 	}
 
 	cmd.Flags().StringVar(&flags.Alias, "alias", "", "alias for extension")
+	cmd.Flags().BoolVarP(&flags.Yes, "yes", "y", false, "install without confirmation")
 
 	return cmd
 
@@ -256,10 +313,16 @@ func NewCmdExtensionRename(cfg config.Config) *cobra.Command {
 				return fmt.Errorf("extension %s not found", args[0])
 			}
 
+			// the secrets are moved first, the extension keeps its old alias if it fails
+			if err := moveSecrets(cmd.ErrOrStderr(), args[0], args[1], extension); err != nil {
+				return fmt.Errorf("failed to move secrets: %w", err)
+			}
+
 			delete(cfg.Extensions, args[0])
 			cfg.Extensions[args[1]] = extension
 
 			if err := cfg.Save(); err != nil {
+				_ = moveSecrets(io.Discard, args[1], args[0], extension)
 				return fmt.Errorf("failed to save config: %w", err)
 			}
 
@@ -271,7 +334,9 @@ func NewCmdExtensionRename(cfg config.Config) *cobra.Command {
 
 func NewCmdExtensionUpgrade(cfg config.Config) *cobra.Command {
 	flags := struct {
-		All bool
+		All    bool
+		Yes    bool
+		DryRun bool
 	}{}
 
 	cmd := &cobra.Command{
@@ -287,27 +352,82 @@ func NewCmdExtensionUpgrade(cfg config.Config) *cobra.Command {
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			upgrade := func(alias string, extensionConfig config.ExtensionConfig) error {
+				if !extensions.IsRemote(extensionConfig.Origin) {
+					if flags.DryRun {
+						return nil
+					}
+
+					if err := extensions.Upgrade(extensionConfig); err != nil {
+						return err
+					}
+
+					cmd.Printf("✅ Upgraded %s\n", alias)
+					return nil
+				}
+
+				update, err := extensions.Stage(extensionConfig)
+				if err != nil {
+					return err
+				}
+
+				if !update.Changed() {
+					cmd.Printf("✅ %s is already up to date\n", alias)
+					return update.Discard()
+				}
+
+				if flags.DryRun {
+					cmd.Printf("⬆️  %s has an update available (%s → %s)\n", alias, shortHash(update.OldHash), shortHash(update.NewHash))
+					return update.Discard()
+				}
+
+				if !flags.Yes {
+					printUpdate(cmd, alias, update)
+					confirmed, err := confirm(cmd, fmt.Sprintf("Upgrade %s?", alias))
+					if err != nil {
+						_ = update.Discard()
+						return err
+					}
+
+					if !confirmed {
+						cmd.Printf("Skipped %s\n", alias)
+						return update.Discard()
+					}
+				}
+
+				if err := update.Apply(); err != nil {
+					return err
+				}
+
+				cmd.Printf("✅ Upgraded %s (%s → %s)\n", alias, shortHash(update.OldHash), shortHash(update.NewHash))
+				return nil
+			}
+
 			if len(args) > 0 {
 				extension, ok := cfg.Extensions[args[0]]
 				if !ok {
 					return fmt.Errorf("extension %s not found", args[0])
 				}
 
-				if err := extensions.Upgrade(extension); err != nil {
+				if err := upgrade(args[0], extension); err != nil {
 					return fmt.Errorf("failed to upgrade extension: %w", err)
 				}
 
-				cmd.Printf("✅ Upgraded %s\n", args[0])
 				return nil
 			}
 
-			cmd.Printf("Upgrading %d extensions...\n\n", len(cfg.Extensions))
-			for alias, extension := range cfg.Extensions {
-				if err := extensions.Upgrade(extension); err != nil {
+			aliases := cfg.Aliases()
+			sort.Strings(aliases)
+
+			cmd.Printf("Upgrading %d extensions...\n\n", len(aliases))
+			for _, alias := range aliases {
+				if err := upgrade(alias, cfg.Extensions[alias]); err != nil {
 					return fmt.Errorf("failed to upgrade extension %s: %w", alias, err)
 				}
+			}
 
-				cmd.Printf("✅ Upgraded %s\n", alias)
+			if flags.DryRun {
+				return nil
 			}
 
 			cmd.Printf("\n✅ Upgraded all extensions\n")
@@ -316,9 +436,104 @@ func NewCmdExtensionUpgrade(cfg config.Config) *cobra.Command {
 	}
 
 	cmd.Flags().BoolVar(&flags.All, "all", false, "upgrade all extensions")
+	cmd.Flags().BoolVarP(&flags.Yes, "yes", "y", false, "upgrade without confirmation")
+	cmd.Flags().BoolVar(&flags.DryRun, "dry-run", false, "only report the extensions with updates available")
+	cmd.MarkFlagsMutuallyExclusive("yes", "dry-run")
 	return cmd
 }
 
+func printPermissions(cmd *cobra.Command, permissions *sunbeam.Permissions) {
+	if permissions == nil {
+		cmd.Println("⚠️  This extension does not declare its permissions, it will have full access to your system.")
+		return
+	}
+
+	cmd.Println("This extension requests the following permissions:")
+	if permissions.Network {
+		cmd.Println("  network: allowed")
+	} else {
+		cmd.Println("  network: denied")
+	}
+
+	for _, path := range permissions.Read {
+		cmd.Printf("  read: %s\n", path)
+	}
+
+	for _, path := range permissions.Write {
+		cmd.Printf("  write: %s\n", path)
+	}
+
+	for _, name := range permissions.Env {
+		cmd.Printf("  env: %s\n", name)
+	}
+}
+
+func printUpdate(cmd *cobra.Command, alias string, update *extensions.Update) {
+	cmd.Printf("Changes to %s:\n\n", alias)
+	colorize := isatty.IsTerminal(os.Stdout.Fd())
+	for _, line := range strings.Split(strings.TrimSuffix(update.Diff(), "\n"), "\n") {
+		cmd.Println(colorizeDiffLine(line, colorize))
+	}
+
+	if update.ManifestErr != nil {
+		cmd.Printf("\n⚠️  The new manifest could not be inspected in a sandbox (%s), its permissions are unknown.\n", update.ManifestErr)
+	} else if changes := extensions.DiffManifests(update.OldManifest, update.NewManifest); len(changes) > 0 {
+		cmd.Printf("\nManifest changes:\n\n")
+		for _, change := range changes {
+			cmd.Printf("  %s\n", colorizeDiffLine(change, colorize))
+		}
+	}
+
+	cmd.Println()
+}
+
+func colorizeDiffLine(line string, colorize bool) string {
+	if !colorize {
+		return line
+	}
+
+	switch {
+	case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+		return lipgloss.NewStyle().Bold(true).Render(line)
+	case strings.HasPrefix(line, "+"):
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("2")).Render(line)
+	case strings.HasPrefix(line, "-"):
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Render(line)
+	case strings.HasPrefix(line, "@@"), strings.HasPrefix(line, "~"):
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("6")).Render(line)
+	default:
+		return line
+	}
+}
+
+func confirm(cmd *cobra.Command, prompt string) (bool, error) {
+	if !isatty.IsTerminal(os.Stdin.Fd()) {
+		return false, fmt.Errorf("cannot ask for confirmation in non-interactive mode, use --yes")
+	}
+
+	cmd.Printf("%s [y/N] ", prompt)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false, err
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+func shortHash(hash string) string {
+	hash = strings.TrimPrefix(hash, "sha256-")
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+
+	return hash
+}
+
 func NewCmdExtensionList(cfg config.Config) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:     "list",
@@ -371,12 +586,30 @@ func NewCmdExtensionRemove(cfg config.Config) *cobra.Command {
 			return completions, cobra.ShellCompDirectiveNoFileComp
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			removed := make(map[string]config.ExtensionConfig)
 			for _, arg := range args {
-				delete(cfg.Extensions, arg)
+				extensionConfig, ok := cfg.Extensions[arg]
+				if !ok {
+					return fmt.Errorf("extension %s not found", arg)
+				}
+
+				removed[arg] = extensionConfig
 			}
 
-			if err := cfg.Save(); err != nil {
-				return fmt.Errorf("failed to save config: %w", err)
+			// the secrets are removed first, the extension stays installed if it fails
+			for alias, extensionConfig := range removed {
+				if err := moveSecrets(cmd.ErrOrStderr(), alias, "", extensionConfig); err != nil {
+					return fmt.Errorf("failed to remove secrets of %s: %w", alias, err)
+				}
+
+				delete(cfg.Extensions, alias)
+				if err := cfg.Save(); err != nil {
+					return fmt.Errorf("failed to save config: %w", err)
+				}
+			}
+
+			if err := unlockOrigins(cfg, removed); err != nil {
+				return fmt.Errorf("failed to update lockfile: %w", err)
 			}
 
 			if len(args) == 1 {
@@ -412,16 +645,28 @@ func NewCmdExtensionConfigure(cfg config.Config) *cobra.Command {
 				return fmt.Errorf("extension %s has no preferences", args[0])
 			}
 
+			secretPreferences, err := tui.ExtractPreferencesFromSecrets(args[0], extension)
+			if err != nil {
+				return err
+			}
+
 			var inputs []sunbeam.Input
 			for _, input := range extension.Manifest.Preferences {
 				if preference := extensionConfig.Preferences[input.Name]; preference != nil {
 					input.Default = preference
 				}
+				if secret := secretPreferences[input.Name]; secret != nil {
+					input.Default = secret
+				}
 				input.Optional = false
 				inputs = append(inputs, input)
 			}
 
 			form := tui.NewForm(func(m map[string]any) tea.Msg {
+				if err := tui.StoreSecretPreferences(args[0], extension, m); err != nil {
+					return err
+				}
+
 				extensionConfig.Preferences = m
 				cfg.Extensions[args[0]] = extensionConfig
 				if err := cfg.Save(); err != nil {
@@ -430,8 +675,78 @@ func NewCmdExtensionConfigure(cfg config.Config) *cobra.Command {
 
 				return tui.ExitMsg{}
 			}, inputs...)
+			form.OptionsProvider = func(input sunbeam.Input) ([]sunbeam.ListItem, error) {
+				return extension.Options(input, extensionConfig.Preferences, nil)
+			}
 
 			return tui.Draw(form)
 		},
 	}
 }
+
+// moveSecrets moves the secret preferences of an extension to a new alias, or deletes them if the new alias is empty.
+// The secrets are skipped with a warning when they can't be listed, so that broken extensions can still be removed or renamed.
+func moveSecrets(stderr io.Writer, alias string, newAlias string, extensionConfig config.ExtensionConfig) error {
+	// the extension is not run, the secret preferences are listed in the cached manifest
+	manifest, err := extensions.ReadCachedManifest(extensionConfig.Origin)
+	if err != nil {
+		fmt.Fprintf(stderr, "skipping the secrets of %s: %s\n", alias, err)
+		return nil
+	}
+
+	values, err := tui.ExtractPreferencesFromSecrets(alias, extensions.Extension{Manifest: manifest})
+	if errors.Is(err, secrets.ErrUnavailable) {
+		fmt.Fprintf(stderr, "skipping the secrets of %s: %s\n", alias, err)
+		return nil
+	} else if err != nil || len(values) == 0 {
+		return err
+	}
+
+	store, err := secrets.Open()
+	if err != nil {
+		return err
+	}
+
+	for name, value := range values {
+		if newAlias != "" {
+			if err := store.Set(secrets.Key(newAlias, name), value.(string)); err != nil {
+				return err
+			}
+		}
+
+		if err := store.Delete(secrets.Key(alias, name)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// unlockOrigins removes the lock entries of the removed extensions, unless their origin is still used
+func unlockOrigins(cfg config.Config, removed map[string]config.ExtensionConfig) error {
+	lock, err := extensions.LoadLock(extensions.LockPath())
+	if err != nil {
+		return err
+	}
+
+	used := make(map[string]bool)
+	for _, extensionConfig := range cfg.Extensions {
+		used[extensionConfig.Origin] = true
+	}
+
+	var changed bool
+	for _, extensionConfig := range removed {
+		if _, ok := lock.Extensions[extensionConfig.Origin]; !ok || used[extensionConfig.Origin] {
+			continue
+		}
+
+		delete(lock.Extensions, extensionConfig.Origin)
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+
+	return lock.Save()
+}
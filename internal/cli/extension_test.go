@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pomdtr/sunbeam/internal/config"
+	"github.com/pomdtr/sunbeam/internal/extensions"
+	"github.com/pomdtr/sunbeam/internal/utils"
+)
+
+func TestMoveSecretsSkipsBrokenExtensions(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	// an extension with a cached manifest, without secret preferences
+	origin := filepath.Join(t.TempDir(), "ext.sh")
+	hash, err := extensions.Hash(origin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manifestDir := filepath.Join(utils.CacheDir(), "extensions", hash)
+	if err := os.MkdirAll(manifestDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	manifest := `{"title": "Test", "commands": [], "preferences": [{"name": "user", "title": "User", "type": "string"}]}`
+	if err := os.WriteFile(filepath.Join(manifestDir, "manifest.json"), []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		origin  string
+		warning bool
+	}{
+		{
+			name:   "no secret preferences",
+			origin: origin,
+		},
+		{
+			name:    "no cached manifest",
+			origin:  filepath.Join(t.TempDir(), "broken.sh"),
+			warning: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stderr bytes.Buffer
+			if err := moveSecrets(&stderr, "test", "", config.ExtensionConfig{Origin: tt.origin}); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if warned := stderr.Len() > 0; warned != tt.warning {
+				t.Errorf("expected warning %t, got %q", tt.warning, stderr.String())
+			}
+		})
+	}
+}
@@ -57,6 +57,8 @@ See https://pomdtr.github.io/sunbeam for more information.`,
 	rootCmd.AddCommand(NewCmdCopy())
 	rootCmd.AddCommand(NewCmdPaste())
 	rootCmd.AddCommand(NewCmdOpen())
+	rootCmd.AddCommand(NewCmdSandbox())
+	rootCmd.AddCommand(NewCmdCache())
 
 	docCmd := &cobra.Command{
 		Use:    "docs",
@@ -154,6 +156,8 @@ See https://pomdtr.github.io/sunbeam for more information.`,
 		}
 		rootCmd.AddCommand(command)
 	}
+	rootCmd.AddCommand(NewCmdRun(cfg, extensionMap))
+	rootCmd.AddCommand(NewCmdServe(cfg, extensionMap))
 
 	rootCmd.RunE = func(cmd *cobra.Command, args []string) error {
 		if !isatty.IsTerminal(os.Stdout.Fd()) {
@@ -237,7 +241,8 @@ func onelinerListItems(oneliners []config.Oneliner) []sunbeam.ListItem {
 		item := sunbeam.ListItem{
 			Id:          fmt.Sprintf("oneliner - %s", oneliner.Title),
 			Title:       oneliner.Title,
-			Accessories: []string{"Oneliner"},
+			Section:     "Oneliners",
+			Accessories: []sunbeam.Accessory{{Text: "Oneliner"}},
 			Actions: []sunbeam.Action{
 				{
 					Title: "Run",
@@ -273,8 +278,8 @@ func extensionListItems(alias string, extension extensions.Extension, extensionC
 		items = append(items, sunbeam.ListItem{
 			Id:          fmt.Sprintf("%s - %s", alias, rootItem.Title),
 			Title:       rootItem.Title,
-			Subtitle:    extension.Manifest.Title,
-			Accessories: []string{"Command"},
+			Section:     extension.Manifest.Title,
+			Accessories: []sunbeam.Accessory{{Text: "Command"}},
 			Actions: []sunbeam.Action{
 				{
 					Title: "Run",
@@ -289,8 +294,8 @@ func extensionListItems(alias string, extension extensions.Extension, extensionC
 		item := sunbeam.ListItem{
 			Id:          fmt.Sprintf("%s - %s", alias, command.Name),
 			Title:       command.Title,
-			Subtitle:    extension.Manifest.Title,
-			Accessories: []string{"Command"},
+			Section:     extension.Manifest.Title,
+			Accessories: []sunbeam.Accessory{{Text: "Command"}},
 			Actions: []sunbeam.Action{
 				{
 					Title: "Run",
@@ -0,0 +1,463 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pomdtr/sunbeam/internal/config"
+	"github.com/pomdtr/sunbeam/internal/extensions"
+	"github.com/pomdtr/sunbeam/internal/schemas"
+	"github.com/pomdtr/sunbeam/internal/tui"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+	"github.com/spf13/cobra"
+)
+
+func NewCmdRun(cfg config.Config, extensionMap extensions.ExtensionMap) *cobra.Command {
+	var flags struct {
+		params    []string
+		query     string
+		selection string
+		action    string
+		yes       bool
+	}
+
+	cmd := &cobra.Command{
+		Use:   "run <alias> <command>",
+		Short: "Run an extension command without the interface",
+		Long: `Run an extension command without the interface, and print its output as JSON.
+
+Use --select and --action to run one of the actions of the output, as if it was chosen from the interface.
+Actions asking for confirmation are only run if --yes is set.`,
+		GroupID: CommandGroupCore,
+		Args:    cobra.ExactArgs(2),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			switch len(args) {
+			case 0:
+				return cfg.Aliases(), cobra.ShellCompDirectiveNoFileComp
+			case 1:
+				extension, ok := extensionMap[args[0]]
+				if !ok {
+					return nil, cobra.ShellCompDirectiveError
+				}
+
+				var completions []string
+				for _, command := range extension.Manifest.Commands {
+					completions = append(completions, fmt.Sprintf("%s\t%s", command.Name, command.Title))
+				}
+
+				return completions, cobra.ShellCompDirectiveNoFileComp
+			default:
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			alias := args[0]
+			extension, ok := extensionMap[alias]
+			if !ok {
+				return fmt.Errorf("extension %s not found", alias)
+			}
+
+			command, ok := extension.Command(args[1])
+			if !ok {
+				return fmt.Errorf("command %s not found", args[1])
+			}
+
+			params, err := parseParams(command, flags.params)
+			if err != nil {
+				return err
+			}
+
+			runner := headlessRunner{
+				cfg:        cfg,
+				extensions: extensionMap,
+				out:        cmd.OutOrStdout(),
+				yes:        flags.yes,
+			}
+
+			payload, err := runner.payload(alias, extension, command, params)
+			if err != nil {
+				return err
+			}
+			payload.Query = flags.query
+
+			page, err := runner.run(extension, payload)
+			if err != nil {
+				return err
+			}
+
+			if !cmd.Flags().Changed("select") && !cmd.Flags().Changed("action") {
+				return runner.print(page)
+			}
+
+			action, err := selectAction(page, flags.selection, flags.action)
+			if err != nil {
+				return err
+			}
+
+			return runner.execute(alias, extension, payload, action)
+		},
+	}
+
+	cmd.Flags().StringArrayVarP(&flags.params, "param", "p", nil, "param of the command, as key=value")
+	cmd.Flags().StringVarP(&flags.query, "query", "q", "", "query of a search command")
+	cmd.Flags().StringVar(&flags.selection, "select", "", "id or title of the item to select")
+	cmd.Flags().StringVar(&flags.action, "action", "", "title or key of the action to run, defaults to the first one")
+	cmd.Flags().BoolVarP(&flags.yes, "yes", "y", false, "run the action without asking for confirmation")
+
+	return cmd
+}
+
+// parseParams converts the key=value pairs to the type of the params of the command
+func parseParams(command sunbeam.CommandSpec, values []string) (map[string]any, error) {
+	params := make(map[string]any)
+	for _, value := range values {
+		name, value, ok := strings.Cut(value, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid param %s, expected key=value", name)
+		}
+
+		idx := slices.IndexFunc(command.Params, func(input sunbeam.Input) bool {
+			return input.Name == name
+		})
+		if idx == -1 {
+			return nil, fmt.Errorf("unknown param %s", name)
+		}
+
+		param := command.Params[idx]
+		switch param.Type {
+		case sunbeam.InputBoolean:
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value for %s: %s is not a boolean", name, value)
+			}
+			params[name] = b
+		case sunbeam.InputNumber:
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value for %s: %s is not a number", name, value)
+			}
+			params[name] = n
+		case sunbeam.InputSelect:
+			if len(param.Options) > 0 && !slices.Contains(param.Options, value) {
+				return nil, fmt.Errorf("invalid value for %s: %s is not one of %s", name, value, strings.Join(param.Options, ", "))
+			}
+			params[name] = value
+		default:
+			params[name] = value
+		}
+	}
+
+	return params, nil
+}
+
+// selectAction finds the action to run in the output of a command.
+// Without a selection, the actions of the page itself are used.
+func selectAction(page any, selection string, target string) (sunbeam.Action, error) {
+	var actions []sunbeam.Action
+	switch page := page.(type) {
+	case sunbeam.List:
+		actions = page.Actions
+		if selection == "" {
+			break
+		}
+
+		idx := slices.IndexFunc(page.Items, func(item sunbeam.ListItem) bool {
+			return tui.ListItem(item).ID() == selection || item.Title == selection
+		})
+		if idx == -1 {
+			return sunbeam.Action{}, fmt.Errorf("item %s not found", selection)
+		}
+		actions = page.Items[idx].Actions
+	case sunbeam.Grid:
+		actions = page.Actions
+		if selection == "" {
+			break
+		}
+
+		idx := slices.IndexFunc(page.Items, func(item sunbeam.GridItem) bool {
+			return tui.GridItem(item).ID() == selection || item.Title == selection
+		})
+		if idx == -1 {
+			return sunbeam.Action{}, fmt.Errorf("item %s not found", selection)
+		}
+		actions = page.Items[idx].Actions
+	case sunbeam.Detail:
+		if selection != "" {
+			return sunbeam.Action{}, fmt.Errorf("a detail has no items to select")
+		}
+		actions = page.Actions
+	default:
+		return sunbeam.Action{}, fmt.Errorf("the command has no output to select an action from")
+	}
+
+	if len(actions) == 0 {
+		return sunbeam.Action{}, fmt.Errorf("no actions available")
+	}
+
+	if target == "" {
+		return actions[0], nil
+	}
+
+	var available []string
+	for _, action := range actions {
+		if action.Title == target || (action.Key != "" && action.Key == target) {
+			return action, nil
+		}
+		available = append(available, action.Title)
+	}
+
+	return sunbeam.Action{}, fmt.Errorf("action %s not found, available actions: %s", target, strings.Join(available, ", "))
+}
+
+// headlessRunner runs commands and actions without the interface, printing the pages instead of displaying them
+type headlessRunner struct {
+	cfg        config.Config
+	extensions extensions.ExtensionMap
+	out        io.Writer
+	// yes confirms the actions asking for it, they are refused otherwise
+	yes bool
+}
+
+func (r headlessRunner) payload(alias string, extension extensions.Extension, command sunbeam.CommandSpec, params map[string]any) (sunbeam.Payload, error) {
+	preferences, err := tui.ResolvePreferences(alias, extension, r.cfg.Extensions[alias])
+	if err != nil {
+		return sunbeam.Payload{}, err
+	}
+
+	if missing := requiredInputs(tui.FindMissingPreferences(extension.Manifest.Preferences, preferences)); len(missing) > 0 {
+		return sunbeam.Payload{}, fmt.Errorf("missing preferences: %s, use sunbeam extension configure %s to set them", strings.Join(missing, ", "), alias)
+	}
+
+	if missing := requiredInputs(tui.FindMissingInputs(command.Params, params)); len(missing) > 0 {
+		return sunbeam.Payload{}, fmt.Errorf("missing params: %s", strings.Join(missing, ", "))
+	}
+
+	payload := sunbeam.Payload{
+		Command:     command.Name,
+		Preferences: preferences,
+		Params:      make(map[string]any),
+	}
+
+	for k, v := range params {
+		payload.Params[k] = v
+	}
+
+	return payload, nil
+}
+
+func requiredInputs(inputs []sunbeam.Input) []string {
+	var names []string
+	for _, input := range inputs {
+		if input.Optional {
+			continue
+		}
+
+		names = append(names, input.Name)
+	}
+
+	return names
+}
+
+// run runs the command and returns its validated output, a list, a grid or a detail.
+// Silent and tty commands have no output.
+func (r headlessRunner) run(extension extensions.Extension, payload sunbeam.Payload) (any, error) {
+	command, ok := extension.Command(payload.Command)
+	if !ok {
+		return nil, fmt.Errorf("command %s not found", payload.Command)
+	}
+
+	ctx := context.Background()
+	if timeout := extension.Timeout(command); timeout > 0 && command.Mode != sunbeam.CommandModeTTY {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	switch command.Mode {
+	case sunbeam.CommandModeTTY:
+		cmd, err := extension.CmdContext(ctx, payload)
+		if err != nil {
+			return nil, err
+		}
+
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+
+		return nil, cmd.Run()
+	case sunbeam.CommandModeSilent:
+		_, err := extension.OutputContext(ctx, payload)
+		return nil, err
+	}
+
+	// the items of a stream command are collected in a list
+	if command.Stream {
+		var list sunbeam.List
+		if err := extension.StreamContext(ctx, payload, func(line []byte) error {
+			if err := schemas.ValidateListItem(line); err != nil {
+				return fmt.Errorf("invalid list item: %w", err)
+			}
+
+			var item sunbeam.ListItem
+			if err := json.Unmarshal(line, &item); err != nil {
+				return err
+			}
+
+			list.Items = append(list.Items, item)
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+
+		return list, nil
+	}
+
+	output, err := extension.OutputContext(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := schemas.ValidateOutput(command.Mode, output); err != nil {
+		return nil, fmt.Errorf("invalid output: %w", err)
+	}
+
+	switch command.Mode {
+	case sunbeam.CommandModeDetail:
+		var detail sunbeam.Detail
+		if err := json.Unmarshal(output, &detail); err != nil {
+			return nil, err
+		}
+
+		return detail, nil
+	case sunbeam.CommandModeGrid:
+		var grid sunbeam.Grid
+		if err := json.Unmarshal(output, &grid); err != nil {
+			return nil, err
+		}
+
+		return grid, nil
+	default:
+		var list sunbeam.List
+		if err := json.Unmarshal(output, &list); err != nil {
+			return nil, err
+		}
+
+		return list, nil
+	}
+}
+
+func (r headlessRunner) print(page any) error {
+	if page == nil {
+		return nil
+	}
+
+	encoder := json.NewEncoder(r.out)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+
+	return encoder.Encode(page)
+}
+
+// execute runs the action chosen from the output of the command, with the same executor as the interface.
+// Pages are printed instead of being pushed, and interactive processes are attached to the terminal.
+func (r headlessRunner) execute(alias string, extension extensions.Extension, payload sunbeam.Payload, action sunbeam.Action) error {
+	executor := tui.ActionExecutor{
+		Extension: func(target string) (extensions.Extension, map[string]any, error) {
+			if target == "" || target == alias {
+				return extension, payload.Preferences, nil
+			}
+
+			targetExtension, ok := r.extensions[target]
+			if !ok {
+				return extensions.Extension{}, nil, fmt.Errorf("extension %s not found", target)
+			}
+
+			preferences, err := tui.ResolvePreferences(target, targetExtension, r.cfg.Extensions[target])
+			if err != nil {
+				return extensions.Extension{}, nil, err
+			}
+
+			return targetExtension, preferences, nil
+		},
+		SavePreferences: func(target string, _ extensions.Extension, _ map[string]any) error {
+			return fmt.Errorf("use sunbeam extension configure %s to set the preferences", target)
+		},
+		Source: func() extensions.Extension {
+			return extension
+		},
+		// forms can't be filled without the interface
+		ShowForm: func(form *tui.Form) tea.Cmd {
+			return func() tea.Msg {
+				return fmt.Errorf("missing inputs: %s, use the interface to fill them", strings.Join(form.InputNames(), ", "))
+			}
+		},
+		HideForm: func() {},
+		Reload: func(params map[string]any) tea.Cmd {
+			for k, v := range params {
+				payload.Params[k] = v
+			}
+
+			return func() tea.Msg {
+				return r.reload(extension, payload)
+			}
+		},
+		Focus: func() tea.Cmd {
+			return nil
+		},
+		Confirm: func(action sunbeam.Action, run tea.Cmd) tea.Cmd {
+			if r.yes {
+				return run
+			}
+
+			return func() tea.Msg {
+				return fmt.Errorf("action %s requires confirmation, use --yes to run it", tui.ActionTitle(action))
+			}
+		},
+		Push: func(_ string, extension extensions.Extension, input sunbeam.Payload) tea.Cmd {
+			return func() tea.Msg {
+				page, err := r.run(extension, input)
+				if err != nil {
+					return err
+				}
+
+				return r.print(page)
+			}
+		},
+		ExecProcess: func(cmd *exec.Cmd, fn tea.ExecCallback) tea.Cmd {
+			return func() tea.Msg {
+				cmd.Stdin = os.Stdin
+				cmd.Stdout = os.Stdout
+				cmd.Stderr = os.Stderr
+
+				return fn(cmd.Run())
+			}
+		},
+	}
+
+	return executor.Run(action, func(msg tea.Msg) error {
+		// notifications are printed to stderr, the output is kept for the pages
+		if msg, ok := msg.(tui.ShowNotificationMsg); ok {
+			fmt.Fprintln(os.Stderr, msg.Title)
+		}
+
+		return nil
+	})
+}
+
+// reload runs the original command again, and prints its new output
+func (r headlessRunner) reload(extension extensions.Extension, payload sunbeam.Payload) error {
+	page, err := r.run(extension, payload)
+	if err != nil {
+		return err
+	}
+
+	return r.print(page)
+}
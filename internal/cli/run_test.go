@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+func TestParseParams(t *testing.T) {
+	command := sunbeam.CommandSpec{
+		Name: "search",
+		Params: []sunbeam.Input{
+			{Name: "query", Type: sunbeam.InputString},
+			{Name: "limit", Type: sunbeam.InputNumber},
+			{Name: "all", Type: sunbeam.InputBoolean},
+			{Name: "sort", Type: sunbeam.InputSelect, Options: []string{"asc", "desc"}},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		values   []string
+		expected map[string]any
+		wantErr  bool
+	}{
+		{
+			name:     "empty",
+			expected: map[string]any{},
+		},
+		{
+			name:     "typed",
+			values:   []string{"query=a=b", "limit=10", "all=true", "sort=desc"},
+			expected: map[string]any{"query": "a=b", "limit": 10, "all": true, "sort": "desc"},
+		},
+		{
+			name:     "empty value",
+			values:   []string{"query="},
+			expected: map[string]any{"query": ""},
+		},
+		{
+			name:    "missing separator",
+			values:  []string{"query"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown param",
+			values:  []string{"page=2"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid number",
+			values:  []string{"limit=ten"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid boolean",
+			values:  []string{"all=maybe"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid option",
+			values:  []string{"sort=random"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, err := parseParams(command, tt.values)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", params)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(params, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, params)
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package cli
+
+import (
+	"github.com/pomdtr/sunbeam/internal/sandbox"
+	"github.com/spf13/cobra"
+)
+
+func NewCmdSandbox() *cobra.Command {
+	return &cobra.Command{
+		Use:                "sandbox <command> [args...]",
+		Short:              "Run a command with the sandbox policy of an extension",
+		Hidden:             true,
+		DisableFlagParsing: true,
+		Args:               cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return sandbox.Exec(args)
+		},
+	}
+}
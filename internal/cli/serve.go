@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pomdtr/sunbeam/internal/config"
+	"github.com/pomdtr/sunbeam/internal/extensions"
+	"github.com/pomdtr/sunbeam/internal/server"
+	"github.com/pomdtr/sunbeam/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+func NewCmdServe(cfg config.Config, extensionMap extensions.ExtensionMap) *cobra.Command {
+	var flags struct {
+		addr           string
+		token          string
+		tokenFile      string
+		allowedOrigins []string
+	}
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Expose the extensions over a local HTTP API",
+		Long: `Expose the extensions over a local HTTP API.
+
+The server only listens on loopback addresses. Every request must send the token of the server,
+either in the Authorization header or in the token query param.
+
+The token is read from --token-file, or from the SUNBEAM_SERVER_TOKEN environment variable.
+The --token flag is visible to the other users of the system, it should only be used for testing.
+A random token is generated if none is set.`,
+		GroupID: CommandGroupCore,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			token, err := serverToken(flags.token, flags.tokenFile)
+			if err != nil {
+				return err
+			}
+
+			generated := token == ""
+			if generated {
+				b := make([]byte, 16)
+				if _, err := rand.Read(b); err != nil {
+					return err
+				}
+				token = hex.EncodeToString(b)
+			}
+
+			handler := server.New(extensionMap, token, func(alias string) (map[string]any, error) {
+				return tui.ResolvePreferences(alias, extensionMap[alias], cfg.Extensions[alias])
+			})
+			handler.AllowedOrigins = flags.allowedOrigins
+
+			listener, err := net.Listen("tcp", flags.addr)
+			if err != nil {
+				return err
+			}
+			defer listener.Close()
+
+			// the commands of the extensions must not be reachable from the network
+			if addr, ok := listener.Addr().(*net.TCPAddr); !ok || !addr.IP.IsLoopback() {
+				return fmt.Errorf("refusing to listen on %s, only loopback addresses are allowed", listener.Addr())
+			}
+
+			fmt.Fprintf(cmd.ErrOrStderr(), "Listening on http://%s\n", listener.Addr())
+			if generated {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Token: %s\n", token)
+			}
+
+			srv := &http.Server{
+				Handler:           handler,
+				ReadHeaderTimeout: 10 * time.Second,
+			}
+
+			// the persistent extensions are stopped with the server
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			defer extensions.StopAll()
+
+			go func() {
+				<-ctx.Done()
+				_ = srv.Close()
+			}()
+
+			if err := srv.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&flags.addr, "addr", "localhost:9999", "loopback address to listen on")
+	cmd.Flags().StringVar(&flags.token, "token", "", "token required by the requests, visible in the process list")
+	cmd.Flags().StringVar(&flags.tokenFile, "token-file", "", "file containing the token required by the requests")
+	cmd.MarkFlagsMutuallyExclusive("token", "token-file")
+	cmd.Flags().StringSliceVar(&flags.allowedOrigins, "allow-origin", nil, "allow web pages from this origin to use the server")
+
+	return cmd
+}
+
+// serverToken returns the token passed by the user, or an empty string if the token must be generated
+func serverToken(token string, tokenFile string) (string, error) {
+	// the extensions are started by the server, they must not inherit the token
+	envToken := os.Getenv("SUNBEAM_SERVER_TOKEN")
+	if err := os.Unsetenv("SUNBEAM_SERVER_TOKEN"); err != nil {
+		return "", err
+	}
+
+	if token != "" {
+		return token, nil
+	}
+
+	if tokenFile != "" {
+		bts, err := os.ReadFile(tokenFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read token: %w", err)
+		}
+
+		token := strings.TrimSpace(string(bts))
+		if token == "" {
+			return "", fmt.Errorf("token file %s is empty", tokenFile)
+		}
+
+		return token, nil
+	}
+
+	return envToken, nil
+}
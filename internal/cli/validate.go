@@ -20,6 +20,8 @@ func NewValidateCmd() *cobra.Command {
 
 	cmd.AddCommand(NewCmdValidateList())
 	cmd.AddCommand(NewCmdValidateDetail())
+	cmd.AddCommand(NewCmdValidateGrid())
+	cmd.AddCommand(NewCmdValidateError())
 	cmd.AddCommand(NewCmdValidateManifest())
 	cmd.AddCommand(NewCmdValidateConfig())
 
@@ -54,6 +56,34 @@ func NewCmdValidateList() *cobra.Command {
 	}
 }
 
+func NewCmdValidateGrid() *cobra.Command {
+	return &cobra.Command{
+		Use:   "grid",
+		Short: "Validate a grid",
+		Args:  cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if isatty.IsTerminal(os.Stdin.Fd()) {
+				return fmt.Errorf("no input provided")
+			}
+
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			input, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				return fmt.Errorf("unable to read stdin: %s", err)
+			}
+
+			if err := schemas.ValidateGrid(input); err != nil {
+				return fmt.Errorf("grid is invalid: %s", err)
+			}
+
+			fmt.Println("✅ Grid is valid!")
+			return nil
+		},
+	}
+}
+
 func NewCmdValidateDetail() *cobra.Command {
 	return &cobra.Command{
 		Use:   "detail",
@@ -83,6 +113,34 @@ func NewCmdValidateDetail() *cobra.Command {
 
 }
 
+func NewCmdValidateError() *cobra.Command {
+	return &cobra.Command{
+		Use:   "error",
+		Short: "Validate an error",
+		Args:  cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if isatty.IsTerminal(os.Stdin.Fd()) {
+				return fmt.Errorf("no input provided")
+			}
+
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			input, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				return fmt.Errorf("unable to read stdin: %s", err)
+			}
+
+			if err := schemas.ValidateError(input); err != nil {
+				return fmt.Errorf("error is invalid: %s", err)
+			}
+
+			fmt.Println("✅ Error is valid!")
+			return nil
+		},
+	}
+}
+
 func NewCmdValidateManifest() *cobra.Command {
 	return &cobra.Command{
 		Use:   "manifest",
@@ -1,6 +1,8 @@
 package extensions
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"crypto/sha1"
 	"encoding/hex"
@@ -13,9 +15,11 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/acarl005/stripansi"
 	"github.com/pomdtr/sunbeam/internal/config"
+	"github.com/pomdtr/sunbeam/internal/sandbox"
 	"github.com/pomdtr/sunbeam/internal/schemas"
 	"github.com/pomdtr/sunbeam/internal/utils"
 	"github.com/pomdtr/sunbeam/pkg/sunbeam"
@@ -60,6 +64,45 @@ func (e Extension) Command(name string) (sunbeam.CommandSpec, bool) {
 	return sunbeam.CommandSpec{}, false
 }
 
+// Timeout returns the maximum duration of a command, or 0 if it is not limited.
+// The timeout of the command takes precedence over the one of the manifest.
+func (e Extension) Timeout(command sunbeam.CommandSpec) time.Duration {
+	if command.Timeout > 0 {
+		return time.Duration(command.Timeout) * time.Second
+	}
+
+	return time.Duration(e.Manifest.Timeout) * time.Second
+}
+
+// CommandError is returned when a command fails, times out or is cancelled.
+// Stderr contains the output of the command until then.
+type CommandError struct {
+	Err    error
+	Stderr string
+}
+
+func (e *CommandError) Error() string {
+	var msg string
+	switch {
+	case errors.Is(e.Err, context.DeadlineExceeded):
+		msg = "command timed out"
+	case errors.Is(e.Err, context.Canceled):
+		msg = "command cancelled"
+	default:
+		msg = "command failed"
+	}
+
+	if e.Stderr == "" {
+		return msg
+	}
+
+	return fmt.Sprintf("%s: %s", msg, e.Stderr)
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
 func (e Extension) RootCommands() []sunbeam.CommandSpec {
 	rootCommands := make([]sunbeam.CommandSpec, 0)
 	for _, command := range e.Manifest.Commands {
@@ -73,32 +116,317 @@ func (e Extension) RootCommands() []sunbeam.CommandSpec {
 	return rootCommands
 }
 
+// Options runs the command referenced by the optionsCommand of a select input, and returns its list items.
+// Only the params declared by the command are forwarded to it.
+func (e Extension) Options(input sunbeam.Input, preferences map[string]any, params map[string]any) ([]sunbeam.ListItem, error) {
+	command, ok := e.Command(input.OptionsCommand)
+	if !ok {
+		return nil, fmt.Errorf("command %s not found", input.OptionsCommand)
+	}
+
+	payload := sunbeam.Payload{
+		Command:     command.Name,
+		Preferences: make(map[string]any),
+		Params:      make(map[string]any),
+	}
+
+	for k, v := range preferences {
+		payload.Preferences[k] = v
+	}
+
+	for _, spec := range command.Params {
+		if value, ok := params[spec.Name]; ok {
+			payload.Params[spec.Name] = value
+		}
+	}
+
+	output, err := e.Output(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := schemas.ValidateList(output); err != nil {
+		return nil, err
+	}
+
+	var list sunbeam.List
+	if err := json.Unmarshal(output, &list); err != nil {
+		return nil, err
+	}
+
+	return list.Items, nil
+}
+
 func (e Extension) Run(input sunbeam.Payload) error {
 	_, err := e.Output(input)
 	return err
 }
 
 func (ext Extension) Output(input sunbeam.Payload) ([]byte, error) {
-	cmd, err := ext.Cmd(input)
+	return ext.OutputContext(context.Background(), input)
+}
+
+// OutputContext runs the command and returns its output.
+// Persistent extensions are reused across calls instead of spawning a new process.
+func (ext Extension) OutputContext(ctx context.Context, input sunbeam.Payload) ([]byte, error) {
+	command, ok := ext.Command(input.Command)
+	if !ok {
+		return nil, fmt.Errorf("command %s not found", input.Command)
+	}
+
+	if timeout := ext.Timeout(command); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	if ext.Manifest.Persistent {
+		switch command.Mode {
+		case sunbeam.CommandModeSearch, sunbeam.CommandModeFilter, sunbeam.CommandModeDetail, sunbeam.CommandModeGrid:
+			payload, err := ext.resolvePayload(input)
+			if err != nil {
+				return nil, err
+			}
+
+			process, err := getProcess(ext)
+			if err != nil {
+				return nil, err
+			}
+
+			return process.Call(ctx, payload)
+		}
+	}
+
+	cmd, err := ext.CmdContext(ctx, input)
 	if err != nil {
 		return nil, err
 	}
+	setProcessGroup(cmd)
 
-	var exitErr *exec.ExitError
-	if output, err := cmd.Output(); err == nil {
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err == nil {
 		return output, nil
+	}
+
+	var exitErr *exec.ExitError
+	if ctx.Err() != nil {
+		return nil, &CommandError{Err: ctx.Err(), Stderr: stripansi.Strip(stderr.String())}
 	} else if errors.As(err, &exitErr) {
-		return nil, fmt.Errorf("command failed: %s", stripansi.Strip(string(exitErr.Stderr)))
+		if structured, ok := parseError(stderr.Bytes()); ok {
+			return nil, structured
+		}
+
+		return nil, &CommandError{Err: err, Stderr: stripansi.Strip(stderr.String())}
 	} else {
 		return nil, err
 	}
 }
 
+// parseError extracts the error object printed by the extension on the last line of stderr, if any
+func parseError(stderr []byte) (*sunbeam.Error, bool) {
+	lines := bytes.Split(bytes.TrimSpace(stderr), []byte("\n"))
+	line := lines[len(lines)-1]
+	if !bytes.HasPrefix(line, []byte("{")) {
+		return nil, false
+	}
+
+	if err := schemas.ValidateError(line); err != nil {
+		return nil, false
+	}
+
+	var structured sunbeam.Error
+	if err := json.Unmarshal(line, &structured); err != nil {
+		return nil, false
+	}
+
+	return &structured, true
+}
+
+// StreamContext runs the command and calls fn for each non-empty line of its output.
+func (ext Extension) StreamContext(ctx context.Context, input sunbeam.Payload, fn func(line []byte) error) error {
+	command, ok := ext.Command(input.Command)
+	if !ok {
+		return fmt.Errorf("command %s not found", input.Command)
+	}
+
+	if timeout := ext.Timeout(command); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	cmd, err := ext.CmdContext(ctx, input)
+	if err != nil {
+		return err
+	}
+	setProcessGroup(cmd)
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		if err := fn(line); err != nil {
+			_ = cmd.Process.Kill()
+			_ = cmd.Wait()
+			if ctx.Err() != nil {
+				return &CommandError{Err: ctx.Err(), Stderr: stripansi.Strip(stderr.String())}
+			}
+
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return err
+	}
+
+	if err := cmd.Wait(); err != nil {
+		var exitErr *exec.ExitError
+		if ctx.Err() != nil {
+			return &CommandError{Err: ctx.Err(), Stderr: stripansi.Strip(stderr.String())}
+		} else if errors.As(err, &exitErr) {
+			if structured, ok := parseError(stderr.Bytes()); ok {
+				return structured
+			}
+
+			return &CommandError{Err: err, Stderr: stripansi.Strip(stderr.String())}
+		}
+
+		return err
+	}
+
+	return nil
+}
+
 func (e Extension) Cmd(input sunbeam.Payload) (*exec.Cmd, error) {
 	return e.CmdContext(context.Background(), input)
 }
 
 func (e Extension) CmdContext(ctx context.Context, input sunbeam.Payload) (*exec.Cmd, error) {
+	input, err := e.resolvePayload(input)
+	if err != nil {
+		return nil, err
+	}
+
+	inputBytes, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, e.Entrypoint, string(inputBytes))
+	if err := e.sandbox(cmd); err != nil {
+		return nil, err
+	}
+
+	cmd.Env = append(cmd.Env, "SUNBEAM=1")
+	return cmd, nil
+}
+
+// sandbox restricts the command to the permissions declared in the manifest.
+// Extensions without permissions run with the full environment.
+func (e Extension) sandbox(cmd *exec.Cmd) error {
+	cmd.Dir = filepath.Dir(e.Entrypoint)
+	cmd.Env = os.Environ()
+	if e.Manifest.Permissions == nil {
+		return nil
+	}
+
+	return sandbox.Wrap(cmd, cmd.Dir, *e.Manifest.Permissions)
+}
+
+// Restrict confines a process started on behalf of the extension, such as the command of an exec action,
+// to the permissions declared in the manifest. Unlike the commands of the extension, it keeps its working directory.
+func (e Extension) Restrict(cmd *exec.Cmd) error {
+	if e.Manifest.Permissions == nil {
+		return nil
+	}
+
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+
+	return sandbox.Wrap(cmd, filepath.Dir(e.Entrypoint), *e.Manifest.Permissions)
+}
+
+// SandboxLimitations lists the declared permissions that the sandbox can't enforce on this system
+func (e Extension) SandboxLimitations() []string {
+	if e.Manifest.Permissions == nil {
+		return nil
+	}
+
+	return sandbox.Limitations(filepath.Dir(e.Entrypoint), *e.Manifest.Permissions)
+}
+
+// CheckURL returns an error if the extension is not allowed to open the url.
+// Opening a url sends data outside of the sandbox, so it requires the network permission.
+func (e Extension) CheckURL(rawURL string) error {
+	permissions := e.Manifest.Permissions
+	if permissions == nil {
+		return nil
+	}
+
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return fmt.Errorf("%s is not allowed to open %s urls", e.Manifest.Title, target.Scheme)
+	}
+
+	if !permissions.Network {
+		return fmt.Errorf("%s is not allowed to open urls without the network permission", e.Manifest.Title)
+	}
+
+	return nil
+}
+
+// CheckPath returns an error if the path is outside of the paths the extension was granted access to
+func (e Extension) CheckPath(path string) error {
+	permissions := e.Manifest.Permissions
+	if permissions == nil {
+		return nil
+	}
+
+	if strings.HasPrefix(path, "~") {
+		path = strings.Replace(path, "~", os.Getenv("HOME"), 1)
+	}
+
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	if !sandbox.Granted(filepath.Dir(e.Entrypoint), *permissions, path) {
+		return fmt.Errorf("%s is not allowed to access %s", e.Manifest.Title, path)
+	}
+
+	return nil
+}
+
+// resolvePayload fills the missing preferences and params with their defaults
+func (e Extension) resolvePayload(input sunbeam.Payload) (sunbeam.Payload, error) {
 	if input.Preferences == nil {
 		input.Preferences = make(map[string]any)
 	}
@@ -109,7 +437,7 @@ func (e Extension) CmdContext(ctx context.Context, input sunbeam.Payload) (*exec
 		}
 
 		if !spec.Optional {
-			return nil, fmt.Errorf("missing required preference %s", spec.Name)
+			return sunbeam.Payload{}, fmt.Errorf("missing required preference %s", spec.Name)
 		}
 
 		input.Preferences[spec.Name] = spec.Default
@@ -117,7 +445,7 @@ func (e Extension) CmdContext(ctx context.Context, input sunbeam.Payload) (*exec
 
 	command, ok := e.Command(input.Command)
 	if !ok {
-		return nil, fmt.Errorf("command %s not found", input.Command)
+		return sunbeam.Payload{}, fmt.Errorf("command %s not found", input.Command)
 	}
 
 	if input.Params == nil {
@@ -130,7 +458,7 @@ func (e Extension) CmdContext(ctx context.Context, input sunbeam.Payload) (*exec
 		}
 
 		if !spec.Optional {
-			return nil, fmt.Errorf("missing required parameter %s", spec.Name)
+			return sunbeam.Payload{}, fmt.Errorf("missing required parameter %s", spec.Name)
 		}
 
 		input.Params[spec.Name] = spec.Default
@@ -138,20 +466,11 @@ func (e Extension) CmdContext(ctx context.Context, input sunbeam.Payload) (*exec
 
 	cwd, err := os.Getwd()
 	if err != nil {
-		return nil, err
+		return sunbeam.Payload{}, err
 	}
 	input.Cwd = cwd
 
-	inputBytes, err := json.Marshal(input)
-	if err != nil {
-		return nil, err
-	}
-
-	cmd := exec.CommandContext(ctx, e.Entrypoint, string(inputBytes))
-	cmd.Dir = filepath.Dir(e.Entrypoint)
-	cmd.Env = os.Environ()
-	cmd.Env = append(cmd.Env, "SUNBEAM=1")
-	return cmd, nil
+	return input, nil
 }
 
 func Hash(origin string) (string, error) {
@@ -243,11 +562,30 @@ func LoadExtension(origin string) (Extension, error) {
 		return Extension{}, err
 	}
 	extensionDir := filepath.Join(utils.CacheDir(), "extensions", hash)
+
 	entrypoint, err := LoadEntrypoint(origin, extensionDir)
 	if err != nil {
 		return Extension{}, err
 	}
 
+	if IsRemote(origin) {
+		lock, err := LoadLock(LockPath())
+		if err != nil {
+			return Extension{}, err
+		}
+
+		// extensions installed before the lockfile existed are pinned to their cached version on first use
+		if _, ok := lock.Extensions[origin]; !ok {
+			if err := lock.Pin(origin, entrypoint); err != nil {
+				return Extension{}, err
+			}
+		}
+
+		if err := lock.Verify(origin, entrypoint); err != nil {
+			return Extension{}, err
+		}
+	}
+
 	entrypointInfo, err := os.Stat(entrypoint)
 	if err != nil {
 		return Extension{}, err
@@ -267,14 +605,9 @@ func LoadExtension(origin string) (Extension, error) {
 		}, nil
 	}
 
-	manifestBytes, err := os.ReadFile(manifestPath)
+	manifest, err := readCachedManifest(manifestPath)
 	if err != nil {
-		return Extension{}, fmt.Errorf("failed to read manifest: %w", err)
-	}
-
-	var manifest sunbeam.Manifest
-	if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
-		return Extension{}, fmt.Errorf("failed to decode manifest: %w", err)
+		return Extension{}, err
 	}
 
 	return Extension{
@@ -289,60 +622,90 @@ func cacheManifest(entrypoint string, manifestPath string) (sunbeam.Manifest, er
 		return sunbeam.Manifest{}, fmt.Errorf("failed to extract manifest: %w", err)
 	}
 
+	if err := writeManifest(manifestPath, manifest); err != nil {
+		return sunbeam.Manifest{}, err
+	}
+
+	return manifest, nil
+}
+
+func writeManifest(manifestPath string, manifest sunbeam.Manifest) error {
 	if err := os.MkdirAll(filepath.Dir(manifestPath), 0755); err != nil {
-		return sunbeam.Manifest{}, fmt.Errorf("failed to create directory: %w", err)
+		return fmt.Errorf("failed to create directory: %w", err)
 	}
 
 	f, err := os.Create(manifestPath)
 	if err != nil {
-		return sunbeam.Manifest{}, fmt.Errorf("failed to create manifest: %w", err)
+		return fmt.Errorf("failed to create manifest: %w", err)
 	}
 	defer f.Close()
 
 	if err := json.NewEncoder(f).Encode(manifest); err != nil {
-		return sunbeam.Manifest{}, fmt.Errorf("failed to write manifest: %w", err)
+		return fmt.Errorf("failed to write manifest: %w", err)
 	}
 
-	return manifest, nil
+	return nil
 }
 
-func Upgrade(extensionConfig config.ExtensionConfig) error {
-	hash, err := Hash(extensionConfig.Origin)
+// ErrSandboxUnavailable is returned by Inspect when the sandbox can't confine the extension
+var ErrSandboxUnavailable = errors.New("sandbox is not available on this system")
+
+// inspectTimeout limits the time an untrusted extension has to print its manifest
+const inspectTimeout = 10 * time.Second
+
+// Inspect reads the manifest of an extension that was not approved yet.
+// The extension runs in a sandbox without network access, environment or permissions,
+// and fails with ErrSandboxUnavailable if the system can't confine it.
+func Inspect(entrypoint string) (sunbeam.Manifest, error) {
+	if !sandbox.Available() {
+		return sunbeam.Manifest{}, ErrSandboxUnavailable
+	}
+
+	entrypoint, err := filepath.Abs(entrypoint)
 	if err != nil {
-		return err
+		return sunbeam.Manifest{}, err
 	}
 
-	extensionDir := filepath.Join(utils.CacheDir(), "extensions", hash)
-	manifestPath := filepath.Join(extensionDir, "manifest.json")
-	if IsRemote(extensionConfig.Origin) {
-		originUrl, err := url.Parse(extensionConfig.Origin)
-		if err != nil {
-			return fmt.Errorf("failed to parse origin: %w", err)
-		}
+	if err := os.Chmod(entrypoint, 0755); err != nil {
+		return sunbeam.Manifest{}, err
+	}
 
-		entrypoint := filepath.Join(extensionDir, filepath.Base(originUrl.Path))
-		if err := DownloadEntrypoint(extensionConfig.Origin, entrypoint); err != nil {
-			return err
-		}
+	ctx, cancel := context.WithTimeout(context.Background(), inspectTimeout)
+	defer cancel()
 
-		if _, err := cacheManifest(entrypoint, manifestPath); err != nil {
-			return err
-		}
+	cmd := exec.CommandContext(ctx, entrypoint)
+	cmd.Dir = filepath.Dir(entrypoint)
+	cmd.Env = os.Environ()
+	if err := sandbox.Wrap(cmd, cmd.Dir, sunbeam.Permissions{}); err != nil {
+		return sunbeam.Manifest{}, err
+	}
+	cmd.Env = append(cmd.Env, "SUNBEAM=1")
 
-		return nil
+	return readManifest(cmd)
+}
+
+// ReadCachedManifest returns the manifest cached when the extension was last loaded, without running it
+func ReadCachedManifest(origin string) (sunbeam.Manifest, error) {
+	hash, err := Hash(origin)
+	if err != nil {
+		return sunbeam.Manifest{}, err
 	}
 
-	entrypoint := extensionConfig.Origin
-	if strings.HasPrefix(entrypoint, "~") {
-		entrypoint = strings.Replace(entrypoint, "~", os.Getenv("HOME"), 1)
-	} else if !filepath.IsAbs(entrypoint) {
-		entrypoint = filepath.Join(filepath.Dir(config.Path), entrypoint)
+	return readCachedManifest(filepath.Join(utils.CacheDir(), "extensions", hash, "manifest.json"))
+}
+
+func readCachedManifest(manifestPath string) (sunbeam.Manifest, error) {
+	manifestBytes, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return sunbeam.Manifest{}, fmt.Errorf("failed to read manifest: %w", err)
 	}
 
-	if _, err := cacheManifest(entrypoint, manifestPath); err != nil {
-		return err
+	var manifest sunbeam.Manifest
+	if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+		return sunbeam.Manifest{}, fmt.Errorf("failed to decode manifest: %w", err)
 	}
-	return nil
+
+	return manifest, nil
 }
 
 func ExtractManifest(entrypoint string) (sunbeam.Manifest, error) {
@@ -360,6 +723,11 @@ func ExtractManifest(entrypoint string) (sunbeam.Manifest, error) {
 	cmd.Env = os.Environ()
 	cmd.Env = append(cmd.Env, "SUNBEAM=1")
 
+	return readManifest(cmd)
+}
+
+// readManifest runs the extension without arguments and validates the manifest it prints
+func readManifest(cmd *exec.Cmd) (sunbeam.Manifest, error) {
 	manifestBytes, err := cmd.Output()
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok {
@@ -0,0 +1,142 @@
+package extensions
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pomdtr/sunbeam/internal/config"
+)
+
+// Lock pins the content of remote extensions, keyed by origin
+type Lock struct {
+	Extensions map[string]LockEntry `json:"extensions"`
+	path       string               `json:"-"`
+}
+
+type LockEntry struct {
+	Hash         string    `json:"hash"`
+	DownloadedAt time.Time `json:"downloadedAt"`
+}
+
+// LockPath returns the path of the lockfile, next to the config file
+func LockPath() string {
+	return filepath.Join(filepath.Dir(config.Path), "sunbeam.lock")
+}
+
+func LoadLock(lockPath string) (Lock, error) {
+	lock := Lock{
+		Extensions: make(map[string]LockEntry),
+		path:       lockPath,
+	}
+
+	bts, err := os.ReadFile(lockPath)
+	if os.IsNotExist(err) {
+		return lock, nil
+	} else if err != nil {
+		return Lock{}, fmt.Errorf("failed to read lockfile: %w", err)
+	}
+
+	if err := json.Unmarshal(bts, &lock); err != nil {
+		return Lock{}, fmt.Errorf("failed to decode lockfile: %w", err)
+	}
+
+	if lock.Extensions == nil {
+		lock.Extensions = make(map[string]LockEntry)
+	}
+
+	return lock, nil
+}
+
+// Save writes the lock to a temporary file first, so that concurrent readers never see a partial file
+func (l Lock) Save() error {
+	bts, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(l.path), ".sunbeam.lock-*")
+	if err != nil {
+		return fmt.Errorf("failed to write lockfile: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(append(bts, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write lockfile: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write lockfile: %w", err)
+	}
+
+	if err := os.Chmod(f.Name(), 0644); err != nil {
+		return fmt.Errorf("failed to write lockfile: %w", err)
+	}
+
+	if err := os.Rename(f.Name(), l.path); err != nil {
+		return fmt.Errorf("failed to write lockfile: %w", err)
+	}
+
+	return nil
+}
+
+// Pin records the current hash of the file for the origin, and saves the lock
+func (l Lock) Pin(origin string, entrypoint string) error {
+	hash, err := HashFile(entrypoint)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(entrypoint)
+	if err != nil {
+		return err
+	}
+
+	l.Extensions[origin] = LockEntry{
+		Hash:         hash,
+		DownloadedAt: info.ModTime().UTC(),
+	}
+
+	return l.Save()
+}
+
+// Verify checks that the file matches the hash pinned for the origin.
+// The lock is never modified, use Pin to record a new origin.
+func (l Lock) Verify(origin string, entrypoint string) error {
+	entry, ok := l.Extensions[origin]
+	if !ok {
+		return fmt.Errorf("%s is not pinned in %s", origin, filepath.Base(l.path))
+	}
+
+	hash, err := HashFile(entrypoint)
+	if err != nil {
+		return err
+	}
+
+	if entry.Hash != hash {
+		return fmt.Errorf("hash mismatch for %s: expected %s, got %s", origin, entry.Hash, hash)
+	}
+
+	return nil
+}
+
+func HashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("sha256-%s", hex.EncodeToString(h.Sum(nil))), nil
+}
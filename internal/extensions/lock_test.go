@@ -0,0 +1,161 @@
+package extensions
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pomdtr/sunbeam/internal/config"
+	"github.com/pomdtr/sunbeam/internal/utils"
+)
+
+func TestLockVerify(t *testing.T) {
+	dir := t.TempDir()
+	entrypoint := filepath.Join(dir, "ext.sh")
+	if err := os.WriteFile(entrypoint, []byte("#!/bin/sh\necho hello\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	hash, err := HashFile(entrypoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	origin := "https://example.com/ext.sh"
+	tests := []struct {
+		name    string
+		entries map[string]LockEntry
+		err     string
+	}{
+		{
+			name:    "pinned",
+			entries: map[string]LockEntry{origin: {Hash: hash}},
+		},
+		{
+			name:    "mismatch",
+			entries: map[string]LockEntry{origin: {Hash: "sha256-0000"}},
+			err:     "hash mismatch",
+		},
+		{
+			name:    "not pinned",
+			entries: map[string]LockEntry{"https://example.com/other.sh": {Hash: hash}},
+			err:     "is not pinned",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lockPath := filepath.Join(t.TempDir(), "sunbeam.lock")
+			lock := Lock{Extensions: tt.entries, path: lockPath}
+
+			err := lock.Verify(origin, entrypoint)
+			switch {
+			case tt.err == "" && err != nil:
+				t.Fatalf("unexpected error: %s", err)
+			case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+				t.Fatalf("expected an error containing %q, got %v", tt.err, err)
+			}
+
+			if _, err := os.Stat(lockPath); !os.IsNotExist(err) {
+				t.Errorf("verify must not write the lockfile")
+			}
+
+			if len(lock.Extensions) != len(tt.entries) {
+				t.Errorf("verify must not pin new origins")
+			}
+		})
+	}
+}
+
+func TestLockSaveLoad(t *testing.T) {
+	lockPath := filepath.Join(t.TempDir(), "sunbeam.lock")
+
+	lock, err := LoadLock(lockPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(lock.Extensions) != 0 {
+		t.Fatalf("expected an empty lock, got %v", lock.Extensions)
+	}
+
+	downloadedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	lock.Extensions["https://example.com/ext.sh"] = LockEntry{Hash: "sha256-abcd", DownloadedAt: downloadedAt}
+	if err := lock.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadLock(lockPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry, ok := loaded.Extensions["https://example.com/ext.sh"]
+	if !ok || entry.Hash != "sha256-abcd" || !entry.DownloadedAt.Equal(downloadedAt) {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(lockPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 1 {
+		t.Errorf("expected the temporary file to be removed, found %d files", len(entries))
+	}
+}
+
+func TestLoadExtensionPinsOnFirstUse(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	configDir := t.TempDir()
+	configPath := config.Path
+	config.Path = filepath.Join(configDir, "sunbeam.json")
+	t.Cleanup(func() { config.Path = configPath })
+
+	// the extension was downloaded before the lockfile existed
+	origin := "https://example.com/ext.sh"
+	hash, err := Hash(origin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	extensionDir := filepath.Join(utils.CacheDir(), "extensions", hash)
+	if err := os.MkdirAll(extensionDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	entrypoint := filepath.Join(extensionDir, "ext.sh")
+	script := "#!/bin/sh\necho '{\"title\": \"Test\", \"commands\": []}'\n"
+	if err := os.WriteFile(entrypoint, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadExtension(origin); err != nil {
+		t.Fatalf("expected the cached extension to load, got %s", err)
+	}
+
+	lock, err := LoadLock(LockPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fileHash, err := HashFile(entrypoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if entry, ok := lock.Extensions[origin]; !ok || entry.Hash != fileHash {
+		t.Fatalf("expected the cached version to be pinned, got %+v", lock.Extensions)
+	}
+
+	// once pinned, a modified file is refused
+	if err := os.WriteFile(entrypoint, []byte(script+"# tampered\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadExtension(origin); err == nil || !strings.Contains(err.Error(), "hash mismatch") {
+		t.Errorf("expected a hash mismatch, got %v", err)
+	}
+}
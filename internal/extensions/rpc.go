@@ -0,0 +1,318 @@
+package extensions
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/acarl005/stripansi"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+const (
+	// stopGracePeriod is the time given to a process to exit once its stdin is closed
+	stopGracePeriod = 2 * time.Second
+	// cancelTimeout bounds the time spent notifying a process that a request was cancelled
+	cancelTimeout = time.Second
+)
+
+// persistent extensions are kept alive for the whole session, keyed by entrypoint
+var (
+	processes   = make(map[string]*rpcProcess)
+	processesMu sync.Mutex
+)
+
+type rpcRequest struct {
+	JsonRPC string `json:"jsonrpc"`
+	Id      int64  `json:"id,omitempty"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JsonRPC string          `json:"jsonrpc"`
+	Id      int64           `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e rpcError) Error() string {
+	return fmt.Sprintf("command failed: %s", e.Message)
+}
+
+type rpcProcess struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	// writeMu serializes the writes to stdin, it is never held while waiting for mu
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	nextId  int64
+	pending map[int64]chan rpcResponse
+	stderr  tailBuffer
+
+	done chan struct{}
+	err  error
+}
+
+func startProcess(extension Extension) (*rpcProcess, error) {
+	cmd := exec.Command(extension.Entrypoint)
+	if err := extension.sandbox(cmd); err != nil {
+		return nil, err
+	}
+
+	cmd.Env = append(cmd.Env, "SUNBEAM=1", "SUNBEAM_RPC=1")
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	p := &rpcProcess{
+		cmd:     cmd,
+		stdin:   stdin,
+		pending: make(map[int64]chan rpcResponse),
+		done:    make(chan struct{}),
+	}
+	cmd.Stderr = &p.stderr
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start extension: %w", err)
+	}
+
+	go p.readLoop(stdout)
+	return p, nil
+}
+
+func (p *rpcProcess) readLoop(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+
+	for scanner.Scan() {
+		var res rpcResponse
+		if err := json.Unmarshal(scanner.Bytes(), &res); err != nil {
+			continue
+		}
+
+		p.mu.Lock()
+		ch, ok := p.pending[res.Id]
+		delete(p.pending, res.Id)
+		p.mu.Unlock()
+
+		if ok {
+			ch <- res
+		}
+	}
+
+	err := p.cmd.Wait()
+	if stderr := strings.TrimSpace(stripansi.Strip(p.stderr.String())); stderr != "" {
+		err = fmt.Errorf("extension exited: %s", stderr)
+	} else if err != nil {
+		err = fmt.Errorf("extension exited: %w", err)
+	} else {
+		err = fmt.Errorf("extension exited")
+	}
+
+	p.mu.Lock()
+	p.err = err
+	p.mu.Unlock()
+	close(p.done)
+}
+
+// send writes the request to stdin. The write happens in its own goroutine,
+// a child that does not read its stdin only blocks the request until ctx is done.
+func (p *rpcProcess) send(ctx context.Context, req rpcRequest) error {
+	bts, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		p.writeMu.Lock()
+		defer p.writeMu.Unlock()
+
+		_, err := p.stdin.Write(append(bts, '\n'))
+		errc <- err
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-p.done:
+		return p.err
+	}
+}
+
+// cancel tells the process to stop working on the request, without waiting for it to read its stdin
+func (p *rpcProcess) cancel(id int64) {
+	p.forget(id)
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), cancelTimeout)
+		defer cancel()
+		_ = p.send(ctx, rpcRequest{JsonRPC: "2.0", Method: "$/cancel", Params: map[string]int64{"id": id}})
+	}()
+}
+
+func (p *rpcProcess) Call(ctx context.Context, payload sunbeam.Payload) ([]byte, error) {
+	ch := make(chan rpcResponse, 1)
+
+	p.mu.Lock()
+	p.nextId++
+	id := p.nextId
+	p.pending[id] = ch
+	p.mu.Unlock()
+
+	// the child may block writing its stdout, the read loop must be able to take mu meanwhile
+	if err := p.send(ctx, rpcRequest{JsonRPC: "2.0", Id: id, Method: "run", Params: payload}); err != nil {
+		// the request may still be written once the process reads its stdin
+		if ctx.Err() != nil {
+			p.cancel(id)
+			return nil, &CommandError{Err: ctx.Err(), Stderr: stripansi.Strip(p.stderr.String())}
+		}
+
+		p.forget(id)
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	select {
+	case res := <-ch:
+		if res.Error != nil {
+			if structured, ok := parseError(res.Error.Data); ok {
+				return nil, structured
+			}
+
+			return nil, res.Error
+		}
+
+		return res.Result, nil
+	case <-ctx.Done():
+		p.cancel(id)
+		return nil, &CommandError{Err: ctx.Err(), Stderr: stripansi.Strip(p.stderr.String())}
+	case <-p.done:
+		return nil, p.err
+	}
+}
+
+func (p *rpcProcess) forget(id int64) {
+	p.mu.Lock()
+	delete(p.pending, id)
+	p.mu.Unlock()
+}
+
+// Stop closes stdin and gives the process stopGracePeriod to exit before killing it
+func (p *rpcProcess) Stop() {
+	_ = p.stdin.Close()
+
+	select {
+	case <-p.done:
+	case <-time.After(stopGracePeriod):
+		_ = p.cmd.Process.Kill()
+		<-p.done
+	}
+}
+
+func (p *rpcProcess) exited() bool {
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func getProcess(extension Extension) (*rpcProcess, error) {
+	processesMu.Lock()
+	defer processesMu.Unlock()
+
+	if p, ok := processes[extension.Entrypoint]; ok && !p.exited() {
+		return p, nil
+	}
+
+	p, err := startProcess(extension)
+	if err != nil {
+		return nil, err
+	}
+
+	processes[extension.Entrypoint] = p
+	return p, nil
+}
+
+// Stop shuts down the persistent process of the extension, if any.
+// The next request will start a new one.
+func (e Extension) Stop() {
+	processesMu.Lock()
+	p, ok := processes[e.Entrypoint]
+	delete(processes, e.Entrypoint)
+	processesMu.Unlock()
+
+	if ok {
+		p.Stop()
+	}
+}
+
+// StopAll shuts down every persistent extension process.
+func StopAll() {
+	processesMu.Lock()
+	stopped := processes
+	processes = make(map[string]*rpcProcess)
+	processesMu.Unlock()
+
+	// the processes are given their grace period at the same time
+	var wg sync.WaitGroup
+	for _, p := range stopped {
+		wg.Add(1)
+		go func(p *rpcProcess) {
+			defer wg.Done()
+			p.Stop()
+		}(p)
+	}
+	wg.Wait()
+}
+
+// tailBuffer keeps the last few kilobytes written to it
+type tailBuffer struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+const tailBufferSize = 4096
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > tailBufferSize {
+		b.buf = b.buf[len(b.buf)-tailBufferSize:]
+	}
+
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return string(b.buf)
+}
@@ -0,0 +1,255 @@
+package extensions
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+func TestMain(m *testing.M) {
+	// the test binary plays the persistent extension when it is started by startProcess
+	if os.Getenv("SUNBEAM_RPC") == "1" {
+		fakeExtension()
+		os.Exit(0)
+	}
+
+	os.Exit(m.Run())
+}
+
+// fakeExtension answers the run requests on stdin, the command of the payload selects its behavior:
+//   - echo answers with the query
+//   - slow answers with the query after 200ms
+//   - hang never answers
+//   - cancelled answers with the ids of the cancelled requests
+//   - pid answers with the pid of the process
+//   - sleep stops reading stdin for a second
+//   - exit exits the process
+func fakeExtension() {
+	var mu sync.Mutex
+	encoder := json.NewEncoder(os.Stdout)
+	respond := func(id int64, result any) {
+		bts, _ := json.Marshal(result)
+
+		mu.Lock()
+		defer mu.Unlock()
+		_ = encoder.Encode(rpcResponse{JsonRPC: "2.0", Id: id, Result: bts})
+	}
+
+	var cancelled []int64
+
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var req struct {
+			Id     int64           `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			continue
+		}
+
+		if req.Method == "$/cancel" {
+			var params struct {
+				Id int64 `json:"id"`
+			}
+			_ = json.Unmarshal(req.Params, &params)
+			cancelled = append(cancelled, params.Id)
+			continue
+		}
+
+		var payload sunbeam.Payload
+		_ = json.Unmarshal(req.Params, &payload)
+
+		switch payload.Command {
+		case "echo":
+			respond(req.Id, payload.Query)
+		case "slow":
+			id, query := req.Id, payload.Query
+			time.AfterFunc(200*time.Millisecond, func() { respond(id, query) })
+		case "cancelled":
+			respond(req.Id, cancelled)
+		case "pid":
+			respond(req.Id, os.Getpid())
+		case "sleep":
+			respond(req.Id, nil)
+			time.Sleep(time.Second)
+		case "exit":
+			os.Exit(1)
+		}
+	}
+}
+
+func testProcess(t *testing.T) *rpcProcess {
+	t.Helper()
+	t.Cleanup(StopAll)
+
+	p, err := getProcess(Extension{Entrypoint: os.Args[0]})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return p
+}
+
+func call(t *testing.T, p *rpcProcess, ctx context.Context, command string, query string, result any) error {
+	t.Helper()
+
+	output, err := p.Call(ctx, sunbeam.Payload{Command: command, Query: query})
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(output, result); err != nil {
+		t.Fatalf("invalid result %q: %s", output, err)
+	}
+
+	return nil
+}
+
+func TestRPCMatchesResponses(t *testing.T) {
+	p := testProcess(t)
+
+	// the response of the slow request is written after the response of the next one
+	slow := make(chan string, 1)
+	go func() {
+		var result string
+		if err := call(t, p, context.Background(), "slow", "first", &result); err != nil {
+			result = err.Error()
+		}
+		slow <- result
+	}()
+
+	// wait for the slow request to be sent
+	for {
+		p.mu.Lock()
+		sent := p.nextId > 0
+		p.mu.Unlock()
+		if sent {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	var result string
+	if err := call(t, p, context.Background(), "echo", "second", &result); err != nil {
+		t.Fatal(err)
+	}
+
+	if result != "second" {
+		t.Errorf("expected the second response, got %q", result)
+	}
+
+	if result := <-slow; result != "first" {
+		t.Errorf("expected the first response, got %q", result)
+	}
+}
+
+func TestRPCCancel(t *testing.T) {
+	p := testProcess(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	var result any
+	if err := call(t, p, ctx, "hang", "", &result); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the request to time out, got %v", err)
+	}
+
+	var cancelled []int64
+	// the cancel notification is sent in the background
+	for i := 0; i < 50 && len(cancelled) == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+		if err := call(t, p, context.Background(), "cancelled", "", &cancelled); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(cancelled) != 1 || cancelled[0] != 1 {
+		t.Errorf("expected the first request to be cancelled, got %v", cancelled)
+	}
+
+	p.mu.Lock()
+	pending := len(p.pending)
+	p.mu.Unlock()
+	if pending != 0 {
+		t.Errorf("expected no pending request, got %d", pending)
+	}
+}
+
+func TestRPCWriteRespectsContext(t *testing.T) {
+	p := testProcess(t)
+
+	var result any
+	if err := call(t, p, context.Background(), "sleep", "", &result); err != nil {
+		t.Fatal(err)
+	}
+
+	// the request does not fit in the pipe while the process is not reading its stdin
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if err := call(t, p, ctx, "echo", strings.Repeat("a", 1024*1024), &result); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the request to time out, got %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected the request to return once the context is done, took %s", elapsed)
+	}
+}
+
+func TestRPCRestartsAfterExit(t *testing.T) {
+	p := testProcess(t)
+
+	var pid int
+	if err := call(t, p, context.Background(), "pid", "", &pid); err != nil {
+		t.Fatal(err)
+	}
+
+	var result any
+	if err := call(t, p, context.Background(), "exit", "", &result); err == nil || !strings.Contains(err.Error(), "extension exited") {
+		t.Fatalf("expected the exit to be reported, got %v", err)
+	}
+
+	restarted := testProcess(t)
+	if restarted == p {
+		t.Fatal("expected a new process")
+	}
+
+	var newPid int
+	if err := call(t, restarted, context.Background(), "pid", "", &newPid); err != nil {
+		t.Fatal(err)
+	}
+
+	if newPid == pid {
+		t.Errorf("expected a new pid, got %d", newPid)
+	}
+}
+
+func TestRPCStop(t *testing.T) {
+	p := testProcess(t)
+
+	// the process exits by itself once its stdin is closed
+	start := time.Now()
+	StopAll()
+	if elapsed := time.Since(start); elapsed >= stopGracePeriod {
+		t.Errorf("expected the process to exit before the grace period, took %s", elapsed)
+	}
+
+	if !p.exited() {
+		t.Error("expected the process to exit")
+	}
+
+	if _, err := p.Call(context.Background(), sunbeam.Payload{Command: "echo"}); err == nil {
+		t.Error("expected the stopped process to fail")
+	}
+}
@@ -0,0 +1,330 @@
+package extensions
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aymanbagabas/go-udiff"
+	"github.com/pomdtr/sunbeam/internal/config"
+	"github.com/pomdtr/sunbeam/internal/utils"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+// Update is a new version of a remote extension, downloaded to a staging location.
+// It only replaces the current version once applied.
+type Update struct {
+	Origin string
+
+	OldHash     string
+	NewHash     string
+	OldSource   string
+	NewSource   string
+	OldManifest sunbeam.Manifest
+	NewManifest sunbeam.Manifest
+	// ManifestErr is set when the new manifest could not be inspected in the sandbox.
+	// The manifest is then only read once the update is applied.
+	ManifestErr error
+
+	entrypoint   string
+	stagingPath  string
+	manifestPath string
+}
+
+func Upgrade(extensionConfig config.ExtensionConfig) error {
+	if IsRemote(extensionConfig.Origin) {
+		update, err := Stage(extensionConfig)
+		if err != nil {
+			return err
+		}
+
+		return update.Apply()
+	}
+
+	hash, err := Hash(extensionConfig.Origin)
+	if err != nil {
+		return err
+	}
+
+	extensionDir := filepath.Join(utils.CacheDir(), "extensions", hash)
+	manifestPath := filepath.Join(extensionDir, "manifest.json")
+
+	entrypoint := extensionConfig.Origin
+	if strings.HasPrefix(entrypoint, "~") {
+		entrypoint = strings.Replace(entrypoint, "~", os.Getenv("HOME"), 1)
+	} else if !filepath.IsAbs(entrypoint) {
+		entrypoint = filepath.Join(filepath.Dir(config.Path), entrypoint)
+	}
+
+	if _, err := cacheManifest(entrypoint, manifestPath); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Stage downloads the latest version of a remote extension next to the current one.
+// The new version is not run outside of the sandbox until the update is applied.
+func Stage(extensionConfig config.ExtensionConfig) (*Update, error) {
+	if !IsRemote(extensionConfig.Origin) {
+		return nil, fmt.Errorf("%s is not a remote extension", extensionConfig.Origin)
+	}
+
+	hash, err := Hash(extensionConfig.Origin)
+	if err != nil {
+		return nil, err
+	}
+
+	originUrl, err := url.Parse(extensionConfig.Origin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse origin: %w", err)
+	}
+
+	extensionDir := filepath.Join(utils.CacheDir(), "extensions", hash)
+	stagingDir := filepath.Join(extensionDir, "staging")
+	if err := os.MkdirAll(stagingDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	update := Update{
+		Origin:       extensionConfig.Origin,
+		entrypoint:   filepath.Join(extensionDir, filepath.Base(originUrl.Path)),
+		stagingPath:  filepath.Join(stagingDir, filepath.Base(originUrl.Path)),
+		manifestPath: filepath.Join(extensionDir, "manifest.json"),
+	}
+
+	if err := update.stage(); err != nil {
+		_ = update.Discard()
+		return nil, err
+	}
+
+	return &update, nil
+}
+
+func (u *Update) stage() error {
+	if err := DownloadEntrypoint(u.Origin, u.stagingPath); err != nil {
+		return err
+	}
+
+	newSource, err := os.ReadFile(u.stagingPath)
+	if err != nil {
+		return err
+	}
+	u.NewSource = string(newSource)
+
+	if u.NewHash, err = HashFile(u.stagingPath); err != nil {
+		return err
+	}
+
+	u.NewManifest, u.ManifestErr = Inspect(u.stagingPath)
+
+	oldSource, err := os.ReadFile(u.entrypoint)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	u.OldSource = string(oldSource)
+	if u.OldHash, err = HashFile(u.entrypoint); err != nil {
+		return err
+	}
+
+	// the current version was approved, but there is no need to run it again
+	if manifest, err := readCachedManifest(u.manifestPath); err == nil {
+		u.OldManifest = manifest
+	}
+
+	return nil
+}
+
+func (u Update) Changed() bool {
+	return u.OldHash != u.NewHash
+}
+
+// Diff returns the unified diff between the current and the new source
+func (u Update) Diff() string {
+	name := filepath.Base(u.entrypoint)
+	return udiff.Unified(fmt.Sprintf("a/%s", name), fmt.Sprintf("b/%s", name), u.OldSource, u.NewSource)
+}
+
+// Apply replaces the current version with the staged one, and updates the lockfile.
+// It must only be called once the update was approved, the new version runs to print its manifest.
+func (u Update) Apply() error {
+	manifest, err := ExtractManifest(u.stagingPath)
+	if err != nil {
+		_ = u.Discard()
+		return fmt.Errorf("failed to extract manifest: %w", err)
+	}
+
+	if err := os.Rename(u.stagingPath, u.entrypoint); err != nil {
+		_ = u.Discard()
+		return fmt.Errorf("failed to replace entrypoint: %w", err)
+	}
+
+	if err := u.Discard(); err != nil {
+		return err
+	}
+
+	lock, err := LoadLock(LockPath())
+	if err != nil {
+		return err
+	}
+
+	lock.Extensions[u.Origin] = LockEntry{
+		Hash:         u.NewHash,
+		DownloadedAt: time.Now().UTC(),
+	}
+
+	if err := lock.Save(); err != nil {
+		return err
+	}
+
+	return writeManifest(u.manifestPath, manifest)
+}
+
+// Discard removes the staged version
+func (u Update) Discard() error {
+	return os.RemoveAll(filepath.Dir(u.stagingPath))
+}
+
+// DiffManifests lists the commands, params and preferences that were added, removed or changed
+func DiffManifests(old sunbeam.Manifest, new sunbeam.Manifest) []string {
+	var changes []string
+
+	if old.Title != new.Title {
+		changes = append(changes, fmt.Sprintf("~ title: %q → %q", old.Title, new.Title))
+	}
+
+	if oldPermissions, newPermissions := describePermissions(old.Permissions), describePermissions(new.Permissions); oldPermissions != newPermissions {
+		changes = append(changes, fmt.Sprintf("~ permissions: %s → %s", oldPermissions, newPermissions))
+	}
+
+	changes = append(changes, diffInputs("preference ", old.Preferences, new.Preferences)...)
+
+	oldCommands := make(map[string]sunbeam.CommandSpec)
+	for _, command := range old.Commands {
+		oldCommands[command.Name] = command
+	}
+
+	newCommands := make(map[string]sunbeam.CommandSpec)
+	for _, command := range new.Commands {
+		newCommands[command.Name] = command
+	}
+
+	for _, name := range sortedKeys(oldCommands, newCommands) {
+		oldCommand, inOld := oldCommands[name]
+		newCommand, inNew := newCommands[name]
+
+		switch {
+		case !inOld:
+			changes = append(changes, fmt.Sprintf("+ command %s (%s)", name, newCommand.Mode))
+		case !inNew:
+			changes = append(changes, fmt.Sprintf("- command %s", name))
+		default:
+			if oldCommand.Title != newCommand.Title {
+				changes = append(changes, fmt.Sprintf("~ command %s: title %q → %q", name, oldCommand.Title, newCommand.Title))
+			}
+
+			if oldCommand.Mode != newCommand.Mode {
+				changes = append(changes, fmt.Sprintf("~ command %s: mode %s → %s", name, oldCommand.Mode, newCommand.Mode))
+			}
+
+			if oldCommand.Hidden != newCommand.Hidden {
+				changes = append(changes, fmt.Sprintf("~ command %s: hidden %t → %t", name, oldCommand.Hidden, newCommand.Hidden))
+			}
+
+			changes = append(changes, diffInputs(fmt.Sprintf("param %s.", name), oldCommand.Params, newCommand.Params)...)
+		}
+	}
+
+	return changes
+}
+
+// diffInputs compares inputs by name, prefix is prepended to their names
+func diffInputs(prefix string, old []sunbeam.Input, new []sunbeam.Input) []string {
+	oldInputs := make(map[string]sunbeam.Input)
+	for _, input := range old {
+		oldInputs[input.Name] = input
+	}
+
+	newInputs := make(map[string]sunbeam.Input)
+	for _, input := range new {
+		newInputs[input.Name] = input
+	}
+
+	var changes []string
+	for _, name := range sortedKeys(oldInputs, newInputs) {
+		oldInput, inOld := oldInputs[name]
+		newInput, inNew := newInputs[name]
+
+		switch {
+		case !inOld:
+			changes = append(changes, fmt.Sprintf("+ %s%s (%s)", prefix, name, describeInput(newInput)))
+		case !inNew:
+			changes = append(changes, fmt.Sprintf("- %s%s", prefix, name))
+		case describeInput(oldInput) != describeInput(newInput):
+			changes = append(changes, fmt.Sprintf("~ %s%s: %s → %s", prefix, name, describeInput(oldInput), describeInput(newInput)))
+		}
+	}
+
+	return changes
+}
+
+func describeInput(input sunbeam.Input) string {
+	if input.Optional {
+		return fmt.Sprintf("%s, optional", input.Type)
+	}
+
+	return string(input.Type)
+}
+
+func describePermissions(permissions *sunbeam.Permissions) string {
+	if permissions == nil {
+		return "full access"
+	}
+
+	var parts []string
+	if permissions.Network {
+		parts = append(parts, "network")
+	}
+
+	for _, path := range permissions.Read {
+		parts = append(parts, fmt.Sprintf("read %s", path))
+	}
+
+	for _, path := range permissions.Write {
+		parts = append(parts, fmt.Sprintf("write %s", path))
+	}
+
+	for _, name := range permissions.Env {
+		parts = append(parts, fmt.Sprintf("env %s", name))
+	}
+
+	if len(parts) == 0 {
+		return "none"
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func sortedKeys[T any](maps ...map[string]T) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, m := range maps {
+		for key := range m {
+			if seen[key] {
+				continue
+			}
+
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,142 @@
+package extensions
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pomdtr/sunbeam/internal/config"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+func TestUpdateDiff(t *testing.T) {
+	update := Update{
+		OldSource:  "echo hello\necho world\n",
+		NewSource:  "echo hello\necho sunbeam\n",
+		entrypoint: "/cache/extensions/abc/ext.sh",
+	}
+
+	diff := update.Diff()
+	for _, line := range []string{"--- a/ext.sh", "+++ b/ext.sh", "-echo world", "+echo sunbeam", " echo hello"} {
+		if !strings.Contains(diff, line+"\n") {
+			t.Errorf("diff is missing %q:\n%s", line, diff)
+		}
+	}
+
+	if update := (Update{OldSource: "same\n", NewSource: "same\n"}); update.Diff() != "" {
+		t.Errorf("expected an empty diff, got %q", update.Diff())
+	}
+}
+
+func TestDiffManifests(t *testing.T) {
+	base := sunbeam.Manifest{
+		Title: "GitHub",
+		Preferences: []sunbeam.Input{
+			{Name: "token", Type: sunbeam.InputSecret},
+		},
+		Commands: []sunbeam.CommandSpec{
+			{Name: "list", Title: "List Repositories", Mode: sunbeam.CommandModeFilter},
+			{Name: "view", Title: "View Repository", Mode: sunbeam.CommandModeDetail, Params: []sunbeam.Input{
+				{Name: "repo", Type: sunbeam.InputString},
+			}},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		update   func(m *sunbeam.Manifest)
+		expected []string
+	}{
+		{
+			name:     "unchanged",
+			update:   func(m *sunbeam.Manifest) {},
+			expected: nil,
+		},
+		{
+			name:     "title",
+			update:   func(m *sunbeam.Manifest) { m.Title = "Gitea" },
+			expected: []string{`~ title: "GitHub" → "Gitea"`},
+		},
+		{
+			name: "permissions",
+			update: func(m *sunbeam.Manifest) {
+				m.Permissions = &sunbeam.Permissions{Network: true, Env: []string{"GITHUB_TOKEN"}}
+			},
+			expected: []string{"~ permissions: full access → network, env GITHUB_TOKEN"},
+		},
+		{
+			name: "commands",
+			update: func(m *sunbeam.Manifest) {
+				m.Commands = []sunbeam.CommandSpec{
+					{Name: "list", Title: "List Repos", Mode: sunbeam.CommandModeSearch},
+					{Name: "create", Title: "Create Repository", Mode: sunbeam.CommandModeTTY},
+				}
+			},
+			expected: []string{
+				"+ command create (tty)",
+				`~ command list: title "List Repositories" → "List Repos"`,
+				"~ command list: mode filter → search",
+				"- command view",
+			},
+		},
+		{
+			name: "inputs",
+			update: func(m *sunbeam.Manifest) {
+				m.Preferences = []sunbeam.Input{{Name: "host", Type: sunbeam.InputString, Optional: true}}
+				m.Commands = []sunbeam.CommandSpec{
+					base.Commands[0],
+					{Name: "view", Title: "View Repository", Mode: sunbeam.CommandModeDetail, Params: []sunbeam.Input{
+						{Name: "repo", Type: sunbeam.InputString, Optional: true},
+					}},
+				}
+			},
+			expected: []string{
+				"+ preference host (string, optional)",
+				"- preference token",
+				"~ param view.repo: string → string, optional",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifest := base
+			tt.update(&manifest)
+
+			if changes := DiffManifests(base, manifest); !reflect.DeepEqual(changes, tt.expected) {
+				t.Errorf("expected %q, got %q", tt.expected, changes)
+			}
+		})
+	}
+}
+
+func TestStageDiscardsOnError(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	config.Path = filepath.Join(t.TempDir(), "sunbeam.json")
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	origin := server.URL + "/ext.sh"
+	if _, err := Stage(config.ExtensionConfig{Origin: origin}); err == nil {
+		t.Fatal("expected the download to fail")
+	}
+
+	hash, err := Hash(origin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stagingDir := filepath.Join(os.Getenv("XDG_CACHE_HOME"), "sunbeam", "extensions", hash, "staging")
+	if _, err := os.Stat(stagingDir); !os.IsNotExist(err) {
+		t.Errorf("expected %s to be removed", stagingDir)
+	}
+
+	if _, err := os.Stat(LockPath()); !os.IsNotExist(err) {
+		t.Errorf("expected the lockfile not to be written")
+	}
+}
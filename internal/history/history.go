@@ -2,6 +2,7 @@ package history
 
 import (
 	"encoding/json"
+	"math"
 	"os"
 	"path/filepath"
 	"sort"
@@ -13,44 +14,118 @@ import (
 
 var Path = filepath.Join(utils.CacheDir(), "history.json")
 
+const (
+	// version of the history file, the first version only contained the last usage of each item
+	version = 2
+	// only the most recent launches are used to compute the frecency of an item
+	maxTimestamps = 10
+	// the weight of a launch is divided by two every halfLife
+	halfLife = 7 * 24 * time.Hour
+)
+
+// Entry records how many times an item was launched, and when
+type Entry struct {
+	Count      int     `json:"count"`
+	Timestamps []int64 `json:"timestamps"`
+}
+
 type History struct {
-	entries map[string]int64
+	entries map[string]Entry
 	path    string
 }
 
+type historyFile struct {
+	Version int              `json:"version"`
+	Entries map[string]Entry `json:"entries"`
+}
+
 func Load(historyPath string) (History, error) {
 	bts, err := os.ReadFile(historyPath)
 	if os.IsNotExist(err) {
 		return History{
-			entries: map[string]int64{},
+			entries: map[string]Entry{},
 			path:    historyPath,
 		}, nil
-	} else if os.IsNotExist(err) {
+	} else if err != nil {
 		return History{}, err
 	}
 
-	var entries map[string]int64
-	if err := json.Unmarshal(bts, &entries); err != nil {
-		return History{}, err
+	// the keys of the first version can collide with the fields of the file, so it is migrated whenever the file can't be decoded
+	var file historyFile
+	if err := json.Unmarshal(bts, &file); err != nil || file.Version == 0 {
+		entries, err := migrate(bts)
+		if err != nil {
+			return History{}, err
+		}
+
+		file.Entries = entries
+	}
+
+	if file.Entries == nil {
+		file.Entries = map[string]Entry{}
 	}
 
 	return History{
-		entries: entries,
+		entries: file.Entries,
 		path:    historyPath,
 	}, nil
 }
 
+// migrate converts the first version of the history, mapping each item to the time it was last used
+func migrate(bts []byte) (map[string]Entry, error) {
+	var lastUsed map[string]int64
+	if err := json.Unmarshal(bts, &lastUsed); err != nil {
+		return nil, err
+	}
+
+	entries := make(map[string]Entry, len(lastUsed))
+	for key, timestamp := range lastUsed {
+		entries[key] = Entry{
+			Count:      1,
+			Timestamps: []int64{timestamp},
+		}
+	}
+
+	return entries, nil
+}
+
+// Score returns the frecency of an item: the number of launches, weighted by how recent the last ones are
+func (h History) Score(key string) float64 {
+	entry, ok := h.entries[key]
+	if !ok || len(entry.Timestamps) == 0 {
+		return 0
+	}
+
+	now := time.Now()
+	var weight float64
+	for _, timestamp := range entry.Timestamps {
+		age := now.Sub(time.Unix(timestamp, 0))
+		weight += math.Pow(0.5, float64(max(age, 0))/float64(halfLife))
+	}
+
+	return float64(entry.Count) * weight / float64(len(entry.Timestamps))
+}
+
 func (h History) Sort(items []sunbeam.ListItem) {
-	sort.SliceStable(items, func(i, j int) bool {
-		keyI := items[i].Id
-		keyJ := items[j].Id
+	scores := make(map[string]float64, len(items))
+	for _, item := range items {
+		scores[item.Id] = h.Score(item.Id)
+	}
 
-		return h.entries[keyI] > h.entries[keyJ]
+	sort.SliceStable(items, func(i, j int) bool {
+		return scores[items[i].Id] > scores[items[j].Id]
 	})
 }
 
 func (h History) Update(key string) {
-	h.entries[key] = time.Now().Unix()
+	entry := h.entries[key]
+	entry.Count++
+	entry.Timestamps = append(entry.Timestamps, time.Now().Unix())
+	if len(entry.Timestamps) > maxTimestamps {
+		entry.Timestamps = entry.Timestamps[len(entry.Timestamps)-maxTimestamps:]
+	}
+
+	h.entries[key] = entry
 }
 
 func (h History) Save() error {
@@ -58,7 +133,10 @@ func (h History) Save() error {
 		return err
 	}
 
-	bts, err := json.MarshalIndent(h.entries, "", "  ")
+	bts, err := json.MarshalIndent(historyFile{
+		Version: version,
+		Entries: h.entries,
+	}, "", "  ")
 	if err != nil {
 		return err
 	}
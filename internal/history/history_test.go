@@ -0,0 +1,207 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected map[string]Entry
+		wantErr  bool
+	}{
+		{
+			name:     "first version",
+			content:  `{"github:search": 1700000000, "tldr:list": 1700000100}`,
+			expected: map[string]Entry{"github:search": {Count: 1, Timestamps: []int64{1700000000}}, "tldr:list": {Count: 1, Timestamps: []int64{1700000100}}},
+		},
+		{
+			name:     "first version with colliding keys",
+			content:  `{"entries": 1700000000, "version": 1700000100}`,
+			expected: map[string]Entry{"entries": {Count: 1, Timestamps: []int64{1700000000}}, "version": {Count: 1, Timestamps: []int64{1700000100}}},
+		},
+		{
+			name:     "first version empty",
+			content:  `{}`,
+			expected: map[string]Entry{},
+		},
+		{
+			name:     "current version",
+			content:  `{"version": 2, "entries": {"github:search": {"count": 12, "timestamps": [1700000000, 1700000100]}}}`,
+			expected: map[string]Entry{"github:search": {Count: 12, Timestamps: []int64{1700000000, 1700000100}}},
+		},
+		{
+			name:     "current version without entries",
+			content:  `{"version": 2}`,
+			expected: map[string]Entry{},
+		},
+		{
+			name:    "invalid",
+			content: `[]`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "history.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			history, err := Load(path)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(history.entries, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, history.entries)
+			}
+		})
+	}
+}
+
+func TestSaveMigrated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	if err := os.WriteFile(path, []byte(`{"github:search": 1700000000}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	history.Update("github:search")
+	if err := history.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry := reloaded.entries["github:search"]
+	if entry.Count != 2 || len(entry.Timestamps) != 2 || entry.Timestamps[0] != 1700000000 {
+		t.Errorf("expected the migrated entry to keep its launch, got %v", entry)
+	}
+}
+
+func TestSort(t *testing.T) {
+	now := time.Now()
+	daysAgo := func(days int) int64 {
+		return now.Add(-time.Duration(days) * 24 * time.Hour).Unix()
+	}
+
+	tests := []struct {
+		name     string
+		entries  map[string]Entry
+		items    []string
+		expected []string
+	}{
+		{
+			name:     "unknown items keep their order",
+			entries:  map[string]Entry{},
+			items:    []string{"a", "b", "c"},
+			expected: []string{"a", "b", "c"},
+		},
+		{
+			name:     "launched items first",
+			entries:  map[string]Entry{"c": {Count: 1, Timestamps: []int64{daysAgo(60)}}},
+			items:    []string{"a", "b", "c"},
+			expected: []string{"c", "a", "b"},
+		},
+		{
+			name: "frequent items first",
+			entries: map[string]Entry{
+				"a": {Count: 1, Timestamps: []int64{daysAgo(1)}},
+				"b": {Count: 5, Timestamps: []int64{daysAgo(1), daysAgo(1)}},
+			},
+			items:    []string{"a", "b"},
+			expected: []string{"b", "a"},
+		},
+		{
+			name: "recent items first",
+			entries: map[string]Entry{
+				"a": {Count: 1, Timestamps: []int64{daysAgo(30)}},
+				"b": {Count: 1, Timestamps: []int64{daysAgo(0)}},
+			},
+			items:    []string{"a", "b"},
+			expected: []string{"b", "a"},
+		},
+		{
+			name: "recent launches outweigh old ones",
+			entries: map[string]Entry{
+				"a": {Count: 10, Timestamps: []int64{daysAgo(60), daysAgo(60), daysAgo(60)}},
+				"b": {Count: 2, Timestamps: []int64{daysAgo(0), daysAgo(1)}},
+			},
+			items:    []string{"a", "b"},
+			expected: []string{"b", "a"},
+		},
+		{
+			name: "equal scores keep their order",
+			entries: map[string]Entry{
+				"a": {Count: 2, Timestamps: []int64{daysAgo(3)}},
+				"b": {Count: 2, Timestamps: []int64{daysAgo(3)}},
+			},
+			items:    []string{"b", "a"},
+			expected: []string{"b", "a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			history := History{entries: tt.entries}
+
+			var items []sunbeam.ListItem
+			for _, id := range tt.items {
+				items = append(items, sunbeam.ListItem{Id: id, Title: id})
+			}
+
+			history.Sort(items)
+
+			var ids []string
+			for _, item := range items {
+				ids = append(ids, item.Id)
+			}
+
+			if !reflect.DeepEqual(ids, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, ids)
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	history := History{entries: map[string]Entry{}}
+	for i := 0; i < maxTimestamps+5; i++ {
+		history.Update("a")
+	}
+
+	entry := history.entries["a"]
+	if entry.Count != maxTimestamps+5 {
+		t.Errorf("expected %d launches, got %d", maxTimestamps+5, entry.Count)
+	}
+
+	if len(entry.Timestamps) != maxTimestamps {
+		t.Errorf("expected the last %d timestamps to be kept, got %d", maxTimestamps, len(entry.Timestamps))
+	}
+
+	if history.Score("a") <= history.Score("b") {
+		t.Error("expected a launched item to score higher than an unknown one")
+	}
+}
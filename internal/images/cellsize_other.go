@@ -0,0 +1,7 @@
+//go:build !unix
+
+package images
+
+func cellSize() (int, int) {
+	return defaultCellWidth, defaultCellHeight
+}
@@ -0,0 +1,26 @@
+package images
+
+import (
+	"fmt"
+	"image"
+	"strings"
+)
+
+// renderHalfBlocks draws two pixels per cell, using the foreground and background colors of the ▀ character
+func renderHalfBlocks(img image.Image, cols, rows int) string {
+	resized := resize(img, cols, rows*2)
+
+	lines := make([]string, rows)
+	for row := 0; row < rows; row++ {
+		var b strings.Builder
+		for col := 0; col < cols; col++ {
+			top := resized.RGBAAt(col, row*2)
+			bottom := resized.RGBAAt(col, row*2+1)
+			fmt.Fprintf(&b, "\x1b[38;2;%d;%d;%dm\x1b[48;2;%d;%d;%dm▀", top.R, top.G, top.B, bottom.R, bottom.G, bottom.B)
+		}
+		b.WriteString("\x1b[0m")
+		lines[row] = b.String()
+	}
+
+	return strings.Join(lines, "\n")
+}
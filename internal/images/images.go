@@ -0,0 +1,341 @@
+// Package images renders images in the terminal, using the best graphics protocol available.
+package images
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pomdtr/sunbeam/internal/utils"
+)
+
+type Protocol string
+
+const (
+	ProtocolKitty      Protocol = "kitty"
+	ProtocolSixel      Protocol = "sixel"
+	ProtocolHalfBlocks Protocol = "halfblocks"
+)
+
+const (
+	// protocolEnv allows the user to override the detected protocol
+	protocolEnv = "SUNBEAM_IMAGE_PROTOCOL"
+
+	// used when the terminal does not report its size in pixels
+	defaultCellWidth  = 10
+	defaultCellHeight = 20
+)
+
+var (
+	cacheDir   = filepath.Join(utils.CacheDir(), "images")
+	httpClient = &http.Client{Timeout: 10 * time.Second}
+
+	// ErrLoading is returned by Render until the image is loaded
+	ErrLoading = errors.New("image is loading")
+
+	// output receives the graphics sequences which are sent once per image, outside of the rendered text
+	output io.Writer = os.Stdout
+
+	mu       sync.Mutex
+	loaded   = make(map[string]image.Image)
+	failed   = make(map[string]failure)
+	rendered = make(map[string]string)
+	// requested contains the images which are queued or being loaded, pending the ones not returned by Requests yet
+	requested = make(map[string]bool)
+	pending   []string
+)
+
+const (
+	// failures are retried after this delay, a network error should not hide an image until sunbeam exits
+	retryDelay = 30 * time.Second
+	// images are downscaled once loaded, they are rendered in a few cells at most
+	maxPixels = 1024
+)
+
+type failure struct {
+	err error
+	at  time.Time
+}
+
+// SetOutput sets the writer receiving the graphics sequences, it must be safe to use alongside the renderer
+func SetOutput(w io.Writer) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	output = w
+}
+
+// DetectProtocol guesses the graphics protocol supported by the terminal from the environment
+func DetectProtocol() Protocol {
+	switch Protocol(os.Getenv(protocolEnv)) {
+	case ProtocolKitty:
+		return ProtocolKitty
+	case ProtocolSixel:
+		return ProtocolSixel
+	case ProtocolHalfBlocks:
+		return ProtocolHalfBlocks
+	}
+
+	// multiplexers do not forward graphics sequences by default
+	if os.Getenv("TMUX") != "" || strings.HasPrefix(os.Getenv("TERM"), "screen") {
+		return ProtocolHalfBlocks
+	}
+
+	term, termProgram := os.Getenv("TERM"), os.Getenv("TERM_PROGRAM")
+	if os.Getenv("KITTY_WINDOW_ID") != "" || term == "xterm-kitty" || termProgram == "ghostty" || term == "xterm-ghostty" {
+		return ProtocolKitty
+	}
+
+	if strings.Contains(term, "sixel") || term == "foot" || strings.HasPrefix(term, "foot-") || term == "mlterm" || termProgram == "WezTerm" || termProgram == "iTerm.app" || termProgram == "mintty" {
+		return ProtocolSixel
+	}
+
+	return ProtocolHalfBlocks
+}
+
+// Render returns the image found at src as a block of text, at most cols wide and rows high.
+// The source can be a local path, a file:// url, a data: uri or an http(s) url.
+// It never blocks: until the image is loaded, it returns ErrLoading and requests the image, see Requests.
+func Render(src string, cols int, rows int) (string, error) {
+	if cols <= 0 || rows <= 0 {
+		return "", nil
+	}
+
+	protocol := DetectProtocol()
+	// the image is fitted in the rows that kitty placeholders can address
+	if protocol == ProtocolKitty {
+		rows = min(rows, len(kittyDiacritics))
+	}
+	key := fmt.Sprintf("%s:%d:%d:%s", protocol, cols, rows, src)
+
+	mu.Lock()
+	output, ok := rendered[key]
+	img, isLoaded := loaded[src]
+	var err error
+	if !ok && !isLoaded {
+		err = request(src)
+	}
+	mu.Unlock()
+	if ok || err != nil {
+		return output, err
+	}
+
+	cols, rows = fit(img.Bounds().Dx(), img.Bounds().Dy(), cols, rows)
+	switch protocol {
+	case ProtocolKitty:
+		output, err = renderKitty(img, key, cols, rows)
+	case ProtocolSixel:
+		output, err = renderSixel(img, cols, rows)
+	default:
+		output = renderHalfBlocks(img, cols, rows)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	mu.Lock()
+	rendered[key] = output
+	mu.Unlock()
+
+	return output, nil
+}
+
+// Request queues the image to be loaded, unless it is already loaded or it failed recently
+func Request(src string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, ok := loaded[src]; !ok {
+		_ = request(src)
+	}
+}
+
+// request returns the recent failure of the image, or queues it. mu must be held.
+func request(src string) error {
+	if f, ok := failed[src]; ok {
+		if time.Since(f.at) < retryDelay {
+			return f.err
+		}
+		delete(failed, src)
+	}
+
+	if !requested[src] {
+		requested[src] = true
+		pending = append(pending, src)
+	}
+
+	return ErrLoading
+}
+
+// Requests returns the images queued since the last call, they must be loaded using Load
+func Requests() []string {
+	mu.Lock()
+	defer mu.Unlock()
+
+	srcs := pending
+	pending = nil
+	return srcs
+}
+
+// Load fetches and decodes the image, so that Render can display it.
+// It blocks until the image is downloaded, so it must not be called from the event loop.
+func Load(src string) error {
+	img, err := decode(src)
+	if err == nil {
+		if bounds := img.Bounds(); max(bounds.Dx(), bounds.Dy()) > maxPixels {
+			scale := float64(maxPixels) / float64(max(bounds.Dx(), bounds.Dy()))
+			img = resize(img, max(int(float64(bounds.Dx())*scale), 1), max(int(float64(bounds.Dy())*scale), 1))
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	delete(requested, src)
+	if err != nil {
+		failed[src] = failure{err: err, at: time.Now()}
+		return err
+	}
+
+	loaded[src] = img
+	return nil
+}
+
+// fit returns the size in cells of the image, keeping its aspect ratio.
+// Images are shrunk to fit in the available space, but never enlarged.
+func fit(width, height, maxCols, maxRows int) (int, int) {
+	if width == 0 || height == 0 {
+		return maxCols, maxRows
+	}
+
+	cellWidth, cellHeight := cellSize()
+	cols := min(maxCols, (width+cellWidth-1)/cellWidth)
+	rows := (height * cols * cellWidth) / (width * cellHeight)
+	if rows > maxRows {
+		rows = maxRows
+		cols = (width * rows * cellHeight) / (height * cellWidth)
+	}
+
+	return max(cols, 1), max(rows, 1)
+}
+
+// decode reads and decodes the image found at src
+func decode(src string) (image.Image, error) {
+	bts, err := read(src)
+	if err != nil {
+		return nil, err
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(bts))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image %s: %w", src, err)
+	}
+
+	return img, nil
+}
+
+func read(src string) ([]byte, error) {
+	if strings.HasPrefix(src, "data:") {
+		return decodeDataURI(src)
+	}
+
+	u, err := url.Parse(src)
+	if err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		return fetch(src)
+	}
+
+	if err == nil && u.Scheme == "file" {
+		src = u.Path
+	}
+
+	if strings.HasPrefix(src, "~/") {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		src = filepath.Join(homeDir, src[2:])
+	}
+
+	return os.ReadFile(src)
+}
+
+func decodeDataURI(uri string) ([]byte, error) {
+	metadata, data, ok := strings.Cut(strings.TrimPrefix(uri, "data:"), ",")
+	if !ok {
+		return nil, fmt.Errorf("invalid data uri")
+	}
+
+	if !strings.HasSuffix(metadata, ";base64") {
+		unescaped, err := url.PathUnescape(data)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(unescaped), nil
+	}
+
+	return base64.StdEncoding.DecodeString(data)
+}
+
+// fetch downloads the image, or reads it from the cache if it was already downloaded
+func fetch(src string) ([]byte, error) {
+	hash := sha256.Sum256([]byte(src))
+	cachePath := filepath.Join(cacheDir, hex.EncodeToString(hash[:]))
+	if bts, err := os.ReadFile(cachePath); err == nil {
+		return bts, nil
+	}
+
+	resp, err := httpClient.Get(src)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: %s", src, resp.Status)
+	}
+
+	bts, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return nil, err
+	}
+
+	if err := os.WriteFile(cachePath, bts, 0644); err != nil {
+		return nil, err
+	}
+
+	return bts, nil
+}
+
+// resize scales the image to the given size, using nearest neighbor sampling
+func resize(img image.Image, width, height int) *image.RGBA {
+	bounds := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		srcY := bounds.Min.Y + y*bounds.Dy()/height
+		for x := 0; x < width; x++ {
+			srcX := bounds.Min.X + x*bounds.Dx()/width
+			dst.Set(x, y, img.At(srcX, srcY))
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,127 @@
+package images
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func dataURI(t *testing.T, width, height int) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func TestRenderLoadsInBackground(t *testing.T) {
+	t.Setenv(protocolEnv, string(ProtocolHalfBlocks))
+	src := dataURI(t, 20, 20)
+
+	if _, err := Render(src, 10, 5); !errors.Is(err, ErrLoading) {
+		t.Fatalf("expected the image to be loading, got %v", err)
+	}
+
+	if _, err := Render(src, 10, 5); !errors.Is(err, ErrLoading) {
+		t.Fatalf("expected the image to still be loading, got %v", err)
+	}
+
+	if requests := Requests(); len(requests) != 1 || requests[0] != src {
+		t.Fatalf("expected the image to be requested once, got %d requests", len(requests))
+	}
+
+	if err := Load(src); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := Render(src, 10, 5)
+	if err != nil || output == "" {
+		t.Fatalf("expected the image to be rendered, got %q, %v", output, err)
+	}
+}
+
+func TestRenderRetriesFailures(t *testing.T) {
+	t.Setenv(protocolEnv, string(ProtocolHalfBlocks))
+	src := filepath.Join(t.TempDir(), "missing.png")
+
+	_, _ = Render(src, 10, 5)
+	Requests()
+	if err := Load(src); err == nil {
+		t.Fatal("expected the missing image to fail")
+	}
+
+	if _, err := Render(src, 10, 5); err == nil || errors.Is(err, ErrLoading) {
+		t.Fatalf("expected the failure to be returned, got %v", err)
+	}
+
+	// once the failure expires, the image is requested again
+	mu.Lock()
+	failed[src] = failure{err: failed[src].err, at: time.Now().Add(-retryDelay)}
+	mu.Unlock()
+
+	if _, err := Render(src, 10, 5); !errors.Is(err, ErrLoading) {
+		t.Fatalf("expected the image to be loading again, got %v", err)
+	}
+
+	if requests := Requests(); len(requests) != 1 {
+		t.Fatalf("expected the image to be requested again, got %d requests", len(requests))
+	}
+}
+
+func TestRenderKitty(t *testing.T) {
+	t.Setenv(protocolEnv, string(ProtocolKitty))
+	var transmitted bytes.Buffer
+	SetOutput(&transmitted)
+	t.Cleanup(func() { SetOutput(io.Discard) })
+
+	// a tall image, limited by the rows that placeholders can address
+	src := dataURI(t, 100, 2000)
+	_, _ = Render(src, 40, 100)
+	Requests()
+	if err := Load(src); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := Render(src, 40, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the image was downscaled when loaded
+	mu.Lock()
+	bounds := loaded[src].Bounds()
+	mu.Unlock()
+
+	lines := strings.Split(output, "\n")
+	cols, rows := fit(bounds.Dx(), bounds.Dy(), 40, len(kittyDiacritics))
+	if len(lines) != rows {
+		t.Errorf("expected %d rows, got %d", rows, len(lines))
+	}
+
+	if placeholders := strings.Count(lines[0], string(kittyPlaceholder)); placeholders != cols {
+		t.Errorf("expected %d columns, got %d", cols, placeholders)
+	}
+
+	if strings.Contains(output, "\x1b_G") {
+		t.Error("expected the image data to be transmitted outside of the rendered text")
+	}
+
+	transmissions := strings.Count(transmitted.String(), "a=T")
+	if _, err := Render(src, 40, 100); err != nil {
+		t.Fatal(err)
+	}
+
+	if transmissions != 1 || strings.Count(transmitted.String(), "a=T") != 1 {
+		t.Errorf("expected the image to be transmitted once, got %d transmissions", strings.Count(transmitted.String(), "a=T"))
+	}
+}
@@ -0,0 +1,77 @@
+package images
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"hash/fnv"
+	"image"
+	"image/png"
+	"io"
+	"strings"
+)
+
+const (
+	kittyPlaceholder = '\U0010EEEE'
+	kittyChunkSize   = 4096
+)
+
+// kittyDiacritics encode the row and column of a placeholder cell, see https://sw.kovidgoyal.net/kitty/graphics-protocol/#unicode-placeholders
+var kittyDiacritics = []rune{
+	0x0305, 0x030D, 0x030E, 0x0310, 0x0312, 0x033D, 0x033E, 0x033F, 0x0346, 0x034A,
+	0x034B, 0x034C, 0x0350, 0x0351, 0x0352, 0x0357, 0x035B, 0x0363, 0x0364, 0x0365,
+	0x0366, 0x0367, 0x0368, 0x0369, 0x036A, 0x036B, 0x036C, 0x036D, 0x036E, 0x036F,
+}
+
+// renderKitty transmits the image with a virtual placement, then draws it using unicode placeholders.
+// Unlike regular placements, placeholders are plain text, so they move and disappear with the rest of the view.
+// The image is transmitted once, outside of the returned text, so that redrawing the view only redraws the placeholders.
+func renderKitty(img image.Image, key string, cols, rows int) (string, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return "", err
+	}
+	payload := base64.StdEncoding.EncodeToString(buf.Bytes())
+
+	hash := fnv.New32a()
+	hash.Write([]byte(key))
+	// the id is encoded in the foreground color of the placeholders, it must fit in 24 bits
+	id := hash.Sum32()&0xFFFFFF | 1
+
+	var b strings.Builder
+	for i := 0; i < len(payload); i += kittyChunkSize {
+		end := min(i+kittyChunkSize, len(payload))
+		more := 0
+		if end < len(payload) {
+			more = 1
+		}
+
+		if i == 0 {
+			fmt.Fprintf(&b, "\x1b_Ga=T,U=1,f=100,q=2,i=%d,c=%d,r=%d,m=%d;%s\x1b\\", id, cols, rows, more, payload[i:end])
+		} else {
+			fmt.Fprintf(&b, "\x1b_Gm=%d;%s\x1b\\", more, payload[i:end])
+		}
+	}
+
+	mu.Lock()
+	w := output
+	mu.Unlock()
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return "", err
+	}
+
+	lines := make([]string, rows)
+	for row := 0; row < rows; row++ {
+		var line strings.Builder
+		fmt.Fprintf(&line, "\x1b[38;2;%d;%d;%dm", id>>16&0xFF, id>>8&0xFF, id&0xFF)
+		// the column of the next cells is inferred from the first one
+		line.WriteRune(kittyPlaceholder)
+		line.WriteRune(kittyDiacritics[row])
+		line.WriteRune(kittyDiacritics[0])
+		line.WriteString(strings.Repeat(string(kittyPlaceholder), cols-1))
+		line.WriteString("\x1b[39m")
+		lines[row] = line.String()
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
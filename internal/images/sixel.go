@@ -0,0 +1,88 @@
+package images
+
+import (
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"strings"
+)
+
+// renderSixel draws the image using the sixel protocol.
+// The image is printed on the first line, the following lines are left blank for it.
+func renderSixel(img image.Image, cols, rows int) (string, error) {
+	cellWidth, cellHeight := cellSize()
+	width, height := cols*cellWidth, rows*cellHeight
+
+	paletted := image.NewPaletted(image.Rect(0, 0, width, height), palette.WebSafe)
+	draw.FloydSteinberg.Draw(paletted, paletted.Bounds(), resize(img, width, height), image.Point{})
+
+	var b strings.Builder
+	// save the cursor position, as the terminal moves it below the image
+	b.WriteString("\x1b7\x1bPq")
+	fmt.Fprintf(&b, "\"1;1;%d;%d", width, height)
+	for i, c := range paletted.Palette {
+		r, g, bl, _ := c.RGBA()
+		fmt.Fprintf(&b, "#%d;2;%d;%d;%d", i, r*100/0xFFFF, g*100/0xFFFF, bl*100/0xFFFF)
+	}
+
+	for band := 0; band < height; band += 6 {
+		// the colors used in the band, in order of appearance
+		var colors []uint8
+		used := make(map[uint8]bool)
+		for y := band; y < min(band+6, height); y++ {
+			for x := 0; x < width; x++ {
+				if idx := paletted.ColorIndexAt(x, y); !used[idx] {
+					used[idx] = true
+					colors = append(colors, idx)
+				}
+			}
+		}
+
+		for i, idx := range colors {
+			if i > 0 {
+				b.WriteByte('$')
+			}
+			fmt.Fprintf(&b, "#%d", idx)
+
+			var run int
+			var last byte
+			for x := 0; x < width; x++ {
+				var sixel byte
+				for dy := 0; dy < 6 && band+dy < height; dy++ {
+					if paletted.ColorIndexAt(x, band+dy) == idx {
+						sixel |= 1 << dy
+					}
+				}
+
+				char := sixel + 63
+				if x > 0 && char != last {
+					writeSixelRun(&b, last, run)
+					run = 0
+				}
+				last = char
+				run++
+			}
+			writeSixelRun(&b, last, run)
+		}
+		b.WriteByte('-')
+	}
+	b.WriteString("\x1b\\\x1b8")
+
+	lines := make([]string, rows)
+	for i := range lines {
+		lines[i] = strings.Repeat(" ", cols)
+	}
+	lines[0] = b.String() + lines[0]
+
+	return strings.Join(lines, "\n"), nil
+}
+
+func writeSixelRun(b *strings.Builder, char byte, run int) {
+	if run > 3 {
+		fmt.Fprintf(b, "!%d%c", run, char)
+		return
+	}
+
+	b.WriteString(strings.Repeat(string(char), run))
+}
@@ -0,0 +1,131 @@
+package sandbox
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+// policyEnv is used to pass the policy to the sandbox helper
+const policyEnv = "SUNBEAM_SANDBOX"
+
+// baseEnv lists the variables forwarded to every sandboxed extension
+var baseEnv = []string{
+	"PATH",
+	"HOME",
+	"USER",
+	"LOGNAME",
+	"SHELL",
+	"TERM",
+	"COLORTERM",
+	"LANG",
+	"TZ",
+	"TMPDIR",
+}
+
+// systemPaths are readable by every sandboxed extension, so that interpreters and shared libraries can be loaded
+var systemPaths = []string{
+	"/bin",
+	"/sbin",
+	"/usr",
+	"/lib",
+	"/lib32",
+	"/lib64",
+	"/etc",
+	"/opt",
+	"/nix",
+	"/proc",
+}
+
+type Policy struct {
+	Network bool     `json:"network"`
+	Read    []string `json:"read"`
+	Write   []string `json:"write"`
+}
+
+// Wrap restricts the command to the permissions of the extension.
+// Relative paths are resolved from dir.
+func Wrap(cmd *exec.Cmd, dir string, permissions sunbeam.Permissions) error {
+	cmd.Env = FilterEnv(cmd.Env, permissions.Env)
+	return wrap(cmd, NewPolicy(dir, permissions))
+}
+
+// Limitations lists the permissions that can't be enforced on this system.
+// The extension still runs, with the access the sandbox can't remove.
+func Limitations(dir string, permissions sunbeam.Permissions) []string {
+	return limitations(NewPolicy(dir, permissions))
+}
+
+// FilterEnv only keeps the base variables and the allowed ones
+func FilterEnv(environ []string, allowed []string) []string {
+	var env []string
+	for _, entry := range environ {
+		name, _, _ := strings.Cut(entry, "=")
+		if strings.HasPrefix(name, "LC_") || slices.Contains(baseEnv, name) || slices.Contains(allowed, name) {
+			env = append(env, entry)
+		}
+	}
+
+	return env
+}
+
+func NewPolicy(dir string, permissions sunbeam.Permissions) Policy {
+	policy := Policy{
+		Network: permissions.Network,
+		Read:    append([]string{dir}, systemPaths...),
+		Write:   []string{"/dev", os.TempDir()},
+	}
+
+	for _, path := range permissions.Read {
+		policy.Read = append(policy.Read, resolve(dir, path))
+	}
+
+	for _, path := range permissions.Write {
+		policy.Write = append(policy.Write, resolve(dir, path))
+	}
+
+	return policy
+}
+
+// Granted reports whether the path is covered by the read or write permissions of the extension.
+// Unlike the policy, the system paths readable by every extension are not included.
+func Granted(dir string, permissions sunbeam.Permissions, path string) bool {
+	target := realpath(resolve(dir, path))
+	for _, granted := range append([]string{dir}, append(permissions.Read, permissions.Write...)...) {
+		granted = realpath(resolve(dir, granted))
+		if target == granted || strings.HasPrefix(target, strings.TrimSuffix(granted, string(filepath.Separator))+string(filepath.Separator)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// realpath follows the symlinks of the path, so that a symlink can't be used to reach a file outside of the granted paths
+func realpath(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+
+	return filepath.Clean(path)
+}
+
+func resolve(dir string, path string) string {
+	if path == "~" {
+		return os.Getenv("HOME")
+	}
+
+	if strings.HasPrefix(path, "~/") {
+		return filepath.Join(os.Getenv("HOME"), path[2:])
+	}
+
+	if !filepath.IsAbs(path) {
+		return filepath.Join(dir, path)
+	}
+
+	return path
+}
@@ -0,0 +1,250 @@
+package sandbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	accessFsRead = unix.LANDLOCK_ACCESS_FS_EXECUTE |
+		unix.LANDLOCK_ACCESS_FS_READ_FILE |
+		unix.LANDLOCK_ACCESS_FS_READ_DIR
+
+	accessFsWrite = unix.LANDLOCK_ACCESS_FS_WRITE_FILE |
+		unix.LANDLOCK_ACCESS_FS_REMOVE_DIR |
+		unix.LANDLOCK_ACCESS_FS_REMOVE_FILE |
+		unix.LANDLOCK_ACCESS_FS_MAKE_CHAR |
+		unix.LANDLOCK_ACCESS_FS_MAKE_DIR |
+		unix.LANDLOCK_ACCESS_FS_MAKE_REG |
+		unix.LANDLOCK_ACCESS_FS_MAKE_SOCK |
+		unix.LANDLOCK_ACCESS_FS_MAKE_FIFO |
+		unix.LANDLOCK_ACCESS_FS_MAKE_BLOCK |
+		unix.LANDLOCK_ACCESS_FS_MAKE_SYM |
+		unix.LANDLOCK_ACCESS_FS_REFER |
+		unix.LANDLOCK_ACCESS_FS_TRUNCATE
+
+	// accessFile are the only rights that can be granted on a regular file
+	accessFile = unix.LANDLOCK_ACCESS_FS_EXECUTE |
+		unix.LANDLOCK_ACCESS_FS_READ_FILE |
+		unix.LANDLOCK_ACCESS_FS_WRITE_FILE |
+		unix.LANDLOCK_ACCESS_FS_TRUNCATE
+
+	accessNet = unix.LANDLOCK_ACCESS_NET_BIND_TCP | unix.LANDLOCK_ACCESS_NET_CONNECT_TCP
+)
+
+// wrap runs the command through the sandbox helper, which restricts itself before executing the extension
+func wrap(cmd *exec.Cmd, policy Policy) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate sunbeam executable: %w", err)
+	}
+
+	bts, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+
+	cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", policyEnv, bts))
+	cmd.Args = append([]string{exe, "sandbox"}, cmd.Args...)
+	cmd.Path = exe
+
+	// landlock only supports network rules since ABI v4, use a network namespace on older kernels
+	if !policy.Network && landlockABI() < 4 && userNamespacesEnabled() {
+		cmd.SysProcAttr = &syscall.SysProcAttr{
+			Cloneflags: syscall.CLONE_NEWUSER | syscall.CLONE_NEWNET,
+			UidMappings: []syscall.SysProcIDMap{
+				{ContainerID: os.Getuid(), HostID: os.Getuid(), Size: 1},
+			},
+			GidMappings: []syscall.SysProcIDMap{
+				{ContainerID: os.Getgid(), HostID: os.Getgid(), Size: 1},
+			},
+		}
+	}
+
+	return nil
+}
+
+// Exec applies the policy passed by the parent process, then replaces the current process with the command
+func Exec(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no command provided")
+	}
+
+	var policy Policy
+	if err := json.Unmarshal([]byte(os.Getenv(policyEnv)), &policy); err != nil {
+		return fmt.Errorf("invalid sandbox policy: %w", err)
+	}
+
+	if err := os.Unsetenv(policyEnv); err != nil {
+		return err
+	}
+
+	path, err := exec.LookPath(args[0])
+	if err != nil {
+		return err
+	}
+
+	// landlock restrictions apply to the calling thread, which must be the one calling execve
+	runtime.LockOSThread()
+	if err := restrict(policy); err != nil {
+		return fmt.Errorf("failed to apply sandbox: %w", err)
+	}
+
+	return syscall.Exec(path, args, os.Environ())
+}
+
+// restrict enforces the policy using landlock.
+// Without landlock, the filesystem is not restricted, the limitations are reported at install time.
+func restrict(policy Policy) error {
+	abi := landlockABI()
+	if abi < 1 {
+		return nil
+	}
+
+	var handledFs uint64 = accessFsRead | accessFsWrite
+	if abi < 2 {
+		handledFs &^= unix.LANDLOCK_ACCESS_FS_REFER
+	}
+
+	if abi < 3 {
+		handledFs &^= unix.LANDLOCK_ACCESS_FS_TRUNCATE
+	}
+
+	attr := unix.LandlockRulesetAttr{
+		Access_fs: handledFs,
+	}
+
+	if abi >= 4 && !policy.Network {
+		attr.Access_net = accessNet
+	}
+
+	fd, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr), 0)
+	if errno != 0 {
+		return fmt.Errorf("failed to create ruleset: %w", errno)
+	}
+	defer unix.Close(int(fd))
+
+	for _, path := range policy.Read {
+		if err := addPathRule(int(fd), path, accessFsRead&handledFs); err != nil {
+			return err
+		}
+	}
+
+	for _, path := range policy.Write {
+		if err := addPathRule(int(fd), path, handledFs); err != nil {
+			return err
+		}
+	}
+
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("failed to set no_new_privs: %w", err)
+	}
+
+	if _, _, errno := unix.Syscall(unix.SYS_LANDLOCK_RESTRICT_SELF, fd, 0, 0); errno != 0 {
+		return fmt.Errorf("failed to restrict process: %w", errno)
+	}
+
+	return nil
+}
+
+func addPathRule(rulesetFd int, path string, access uint64) error {
+	fd, err := unix.Open(path, unix.O_PATH|unix.O_CLOEXEC, 0)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer unix.Close(fd)
+
+	var stat unix.Stat_t
+	if err := unix.Fstat(fd, &stat); err != nil {
+		return err
+	}
+
+	if stat.Mode&unix.S_IFMT != unix.S_IFDIR {
+		access &= accessFile
+	}
+
+	rule := unix.LandlockPathBeneathAttr{
+		Allowed_access: access,
+		Parent_fd:      int32(fd),
+	}
+
+	if _, _, errno := unix.Syscall6(unix.SYS_LANDLOCK_ADD_RULE, uintptr(rulesetFd), unix.LANDLOCK_RULE_PATH_BENEATH, uintptr(unsafe.Pointer(&rule)), 0, 0, 0); errno != 0 {
+		return fmt.Errorf("failed to allow %s: %w", path, errno)
+	}
+
+	return nil
+}
+
+// Available reports whether the kernel can confine both the filesystem and the network access of a command
+func Available() bool {
+	abi := landlockABI()
+	if abi < 1 {
+		return false
+	}
+
+	return abi >= 4 || userNamespacesEnabled()
+}
+
+// limitations lists the restrictions of the policy that the kernel can't enforce
+func limitations(policy Policy) []string {
+	var limitations []string
+	abi := landlockABI()
+	if abi < 1 {
+		limitations = append(limitations, "filesystem access is not restricted, the kernel does not support landlock")
+	}
+
+	if !policy.Network && abi < 4 && !userNamespacesEnabled() {
+		limitations = append(limitations, "network access is not restricted, the kernel supports neither landlock network rules nor unprivileged user namespaces")
+	}
+
+	return limitations
+}
+
+// landlockABI returns the landlock ABI version supported by the kernel, or 0 if landlock is not available
+func landlockABI() int {
+	abi, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, 0, 0, unix.LANDLOCK_CREATE_RULESET_VERSION)
+	if errno != 0 {
+		return 0
+	}
+
+	return int(abi)
+}
+
+func userNamespacesEnabled() bool {
+	checks := map[string]string{
+		"/proc/sys/kernel/unprivileged_userns_clone":             "1",
+		"/proc/sys/kernel/apparmor_restrict_unprivileged_userns": "0",
+	}
+
+	for path, expected := range checks {
+		bts, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return false
+		}
+
+		if strings.TrimSpace(string(bts)) != expected {
+			return false
+		}
+	}
+
+	bts, err := os.ReadFile("/proc/sys/user/max_user_namespaces")
+	if err != nil {
+		return false
+	}
+
+	return strings.TrimSpace(string(bts)) != "0"
+}
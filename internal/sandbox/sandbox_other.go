@@ -0,0 +1,26 @@
+//go:build !linux
+
+package sandbox
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// wrap is a no-op outside of linux, only the environment is filtered
+func wrap(cmd *exec.Cmd, policy Policy) error {
+	return nil
+}
+
+// Available is always false outside of linux, commands can't be confined
+func Available() bool {
+	return false
+}
+
+func limitations(policy Policy) []string {
+	return []string{"filesystem and network access are not restricted, the sandbox is only supported on linux"}
+}
+
+func Exec(args []string) error {
+	return fmt.Errorf("sandbox is only supported on linux")
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
 	"github.com/santhosh-tekuri/jsonschema/v5"
 )
 
@@ -16,8 +17,11 @@ var schemas map[string]*jsonschema.Schema
 var schemaUrls = []string{
 	"params.schema.json",
 	"action.schema.json",
+	"metadata.schema.json",
 	"list.schema.json",
+	"grid.schema.json",
 	"detail.schema.json",
+	"error.schema.json",
 	"manifest.schema.json",
 	"config.schema.json",
 }
@@ -46,8 +50,16 @@ func init() {
 
 		schemas[url] = schema
 	}
+
+	item, err := compiler.Compile(listItemSchemaUrl)
+	if err != nil {
+		panic(err)
+	}
+	schemas[listItemSchemaUrl] = item
 }
 
+const listItemSchemaUrl = "list.schema.json#/definitions/item"
+
 func formatValidationError(ve *jsonschema.ValidationError) string {
 	leaf := ve
 	for len(leaf.Causes) > 0 {
@@ -80,6 +92,18 @@ func ValidateList(input []byte) error {
 	return validateSchema("list.schema.json", input)
 }
 
+func ValidateGrid(input []byte) error {
+	return validateSchema("grid.schema.json", input)
+}
+
+func ValidateListItem(input []byte) error {
+	return validateSchema(listItemSchemaUrl, input)
+}
+
+func ValidateError(input []byte) error {
+	return validateSchema("error.schema.json", input)
+}
+
 func ValidateManifest(input []byte) error {
 	return validateSchema("manifest.schema.json", input)
 }
@@ -87,3 +111,17 @@ func ValidateManifest(input []byte) error {
 func ValidateConfig(input []byte) error {
 	return validateSchema("config.schema.json", input)
 }
+
+// ValidateOutput validates the output of a command, depending on its mode
+func ValidateOutput(mode sunbeam.CommandMode, input []byte) error {
+	switch mode {
+	case sunbeam.CommandModeSearch, sunbeam.CommandModeFilter:
+		return ValidateList(input)
+	case sunbeam.CommandModeDetail:
+		return ValidateDetail(input)
+	case sunbeam.CommandModeGrid:
+		return ValidateGrid(input)
+	default:
+		return fmt.Errorf("commands with mode %s have no output", mode)
+	}
+}
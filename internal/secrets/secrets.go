@@ -0,0 +1,209 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"filippo.io/age"
+	"github.com/pomdtr/sunbeam/internal/utils"
+	"github.com/zalando/go-keyring"
+)
+
+const service = "sunbeam"
+
+var (
+	ErrNotFound = errors.New("secret not found")
+	// ErrUnavailable is returned by Open when there is no keyring and no passphrase to encrypt the secrets file
+	ErrUnavailable = errors.New("no keyring available")
+
+	// Path is the location of the encrypted file used when no keyring is available
+	Path = filepath.Join(utils.ConfigDir(), "secrets.age")
+)
+
+type Store interface {
+	Get(key string) (string, error)
+	Set(key string, value string) error
+	Delete(key string) error
+}
+
+var (
+	store     Store
+	storeErr  error
+	storeOnce sync.Once
+)
+
+// Open returns the OS keyring if a keyring daemon is available,
+// and falls back to a passphrase-encrypted file otherwise.
+func Open() (Store, error) {
+	storeOnce.Do(func() {
+		if _, err := keyring.Get(service, "__probe__"); err == nil || errors.Is(err, keyring.ErrNotFound) {
+			store = keyringStore{}
+			return
+		}
+
+		passphrase, ok := os.LookupEnv("SUNBEAM_SECRETS_PASSPHRASE")
+		if !ok {
+			storeErr = fmt.Errorf("%w, set SUNBEAM_SECRETS_PASSPHRASE to store secrets in %s", ErrUnavailable, Path)
+			return
+		}
+
+		store = &fileStore{path: Path, passphrase: passphrase}
+	})
+
+	return store, storeErr
+}
+
+// Key returns the key used to store a secret preference of an extension
+func Key(alias string, name string) string {
+	return fmt.Sprintf("%s.%s", alias, name)
+}
+
+type keyringStore struct{}
+
+func (keyringStore) Get(key string) (string, error) {
+	value, err := keyring.Get(service, key)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", ErrNotFound
+	}
+
+	return value, err
+}
+
+func (keyringStore) Set(key string, value string) error {
+	return keyring.Set(service, key, value)
+}
+
+func (keyringStore) Delete(key string) error {
+	if err := keyring.Delete(service, key); err != nil && !errors.Is(err, keyring.ErrNotFound) {
+		return err
+	}
+
+	return nil
+}
+
+type fileStore struct {
+	path       string
+	passphrase string
+
+	mu      sync.Mutex
+	entries map[string]string
+}
+
+func (s *fileStore) load() error {
+	if s.entries != nil {
+		return nil
+	}
+
+	ciphertext, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		s.entries = make(map[string]string)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	identity, err := age.NewScryptIdentity(s.passphrase)
+	if err != nil {
+		return err
+	}
+
+	r, err := age.Decrypt(bytes.NewReader(ciphertext), identity)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt secrets: %w", err)
+	}
+
+	plaintext, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	var entries map[string]string
+	if err := json.Unmarshal(plaintext, &entries); err != nil {
+		return fmt.Errorf("failed to decode secrets: %w", err)
+	}
+
+	s.entries = entries
+	return nil
+}
+
+func (s *fileStore) save() error {
+	plaintext, err := json.Marshal(s.entries)
+	if err != nil {
+		return err
+	}
+
+	recipient, err := age.NewScryptRecipient(s.passphrase)
+	if err != nil {
+		return err
+	}
+
+	var ciphertext bytes.Buffer
+	w, err := age.Encrypt(&ciphertext, recipient)
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(plaintext); err != nil {
+		return err
+	}
+
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.path, ciphertext.Bytes(), 0600)
+}
+
+func (s *fileStore) Get(key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(); err != nil {
+		return "", err
+	}
+
+	value, ok := s.entries[key]
+	if !ok {
+		return "", ErrNotFound
+	}
+
+	return value, nil
+}
+
+func (s *fileStore) Set(key string, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(); err != nil {
+		return err
+	}
+
+	s.entries[key] = value
+	return s.save()
+}
+
+func (s *fileStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(); err != nil {
+		return err
+	}
+
+	if _, ok := s.entries[key]; !ok {
+		return nil
+	}
+
+	delete(s.entries, key)
+	return s.save()
+}
@@ -0,0 +1,441 @@
+// Package server exposes the extensions over a local HTTP API, allowing other frontends to use them.
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pomdtr/sunbeam/internal/extensions"
+	"github.com/pomdtr/sunbeam/internal/schemas"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+	"golang.org/x/net/websocket"
+)
+
+// Request runs a command, the preferences of the extension are resolved by the server
+type Request struct {
+	// Id is sent back in the events of the websocket, to match them with the request
+	Id     int            `json:"id,omitempty"`
+	Params map[string]any `json:"params,omitempty"`
+	Query  string         `json:"query,omitempty"`
+	Cursor string         `json:"cursor,omitempty"`
+}
+
+// Event is sent through the websocket while a command runs
+type Event struct {
+	Id    int             `json:"id,omitempty"`
+	Type  EventType       `json:"type"`
+	Data  json.RawMessage `json:"data,omitempty"`
+	Error *sunbeam.Error  `json:"error,omitempty"`
+}
+
+type EventType string
+
+const (
+	// EventTypeOutput contains the list, grid or detail printed by the command
+	EventTypeOutput EventType = "output"
+	// EventTypeItem contains a list item printed by a stream command
+	EventTypeItem EventType = "item"
+	// EventTypeDone is sent once a stream command exits
+	EventTypeDone  EventType = "done"
+	EventTypeError EventType = "error"
+)
+
+type Extension struct {
+	Alias    string           `json:"alias"`
+	Manifest sunbeam.Manifest `json:"manifest"`
+}
+
+type Server struct {
+	extensions  extensions.ExtensionMap
+	preferences func(alias string) (map[string]any, error)
+	// token is required by every request, so that other local processes and web pages can't run the commands
+	token string
+
+	// AllowedOrigins are the origins of the web pages allowed to use the server, in addition to its own origin
+	AllowedOrigins []string
+}
+
+func New(extensionMap extensions.ExtensionMap, token string, preferences func(alias string) (map[string]any, error)) *Server {
+	return &Server{
+		extensions:  extensionMap,
+		preferences: preferences,
+		token:       token,
+	}
+}
+
+// ServeHTTP routes the requests:
+//
+//	GET  /extensions                       list the extensions and their manifests
+//	GET  /extensions/{alias}               get the manifest of an extension
+//	POST /extensions/{alias}/{command}     run a command and return its output
+//	GET  /extensions/{alias}/{command}/ws  run a command each time a request is received on the websocket
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	origin := r.Header.Get("Origin")
+	if origin != "" && !s.isAllowed(r, origin) {
+		writeError(w, http.StatusForbidden, fmt.Errorf("origin %s is not allowed", origin))
+		return
+	}
+
+	if origin != "" {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+		w.Header().Set("Vary", "Origin")
+	}
+
+	// preflight requests never carry the token
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if !s.isAuthorized(r) {
+		writeError(w, http.StatusUnauthorized, fmt.Errorf("missing or invalid token"))
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if parts[0] != "extensions" || len(parts) > 4 {
+		writeError(w, http.StatusNotFound, fmt.Errorf("not found: %s", r.URL.Path))
+		return
+	}
+
+	if len(parts) == 1 {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+			return
+		}
+
+		s.listExtensions(w)
+		return
+	}
+
+	alias := parts[1]
+	extension, ok := s.extensions[alias]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("extension %s not found", alias))
+		return
+	}
+
+	if len(parts) == 2 {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+			return
+		}
+
+		writeJSON(w, http.StatusOK, extension.Manifest)
+		return
+	}
+
+	command, ok := extension.Command(parts[2])
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("command %s not found", parts[2]))
+		return
+	}
+
+	if len(parts) == 4 {
+		if parts[3] != "ws" {
+			writeError(w, http.StatusNotFound, fmt.Errorf("not found: %s", r.URL.Path))
+			return
+		}
+
+		// the origin was already checked
+		server := websocket.Server{
+			Handler: func(conn *websocket.Conn) {
+				s.serveWebsocket(conn, alias, extension, command)
+			},
+		}
+		server.ServeHTTP(w, r)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+
+	// the body is optional, its length is unknown when it is chunked
+	var req Request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+		return
+	}
+
+	s.runCommand(w, r.Context(), alias, extension, command, req)
+}
+
+// isAllowed reports if a web page served from the origin can use the server.
+// Other than the allowed origins, only the pages served by the server itself are allowed,
+// the pages served by other local servers are not.
+func (s *Server) isAllowed(r *http.Request, origin string) bool {
+	if slices.Contains(s.AllowedOrigins, origin) {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil || u.Scheme != "http" {
+		return false
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "80"
+	}
+
+	addr, ok := r.Context().Value(http.LocalAddrContextKey).(*net.TCPAddr)
+	if !ok || port != strconv.Itoa(addr.Port) {
+		return false
+	}
+
+	switch u.Hostname() {
+	case "localhost", "127.0.0.1", "::1":
+		return true
+	default:
+		return false
+	}
+}
+
+// isAuthorized reports if the request carries the token of the server.
+// Browsers can't set the headers of a websocket, so the token can also be passed as a query param.
+func (s *Server) isAuthorized(r *http.Request) bool {
+	token := r.URL.Query().Get("token")
+	if auth, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		token = auth
+	}
+
+	return s.token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
+
+func (s *Server) listExtensions(w http.ResponseWriter) {
+	items := make([]Extension, 0, len(s.extensions))
+	for alias, extension := range s.extensions {
+		items = append(items, Extension{
+			Alias:    alias,
+			Manifest: extension.Manifest,
+		})
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Alias < items[j].Alias
+	})
+
+	writeJSON(w, http.StatusOK, items)
+}
+
+func (s *Server) payload(alias string, command sunbeam.CommandSpec, req Request) (sunbeam.Payload, error) {
+	preferences, err := s.preferences(alias)
+	if err != nil {
+		return sunbeam.Payload{}, err
+	}
+
+	return sunbeam.Payload{
+		Command:     command.Name,
+		Preferences: preferences,
+		Params:      req.Params,
+		Query:       req.Query,
+		Cursor:      req.Cursor,
+	}, nil
+}
+
+func (s *Server) runCommand(w http.ResponseWriter, ctx context.Context, alias string, extension extensions.Extension, command sunbeam.CommandSpec, req Request) {
+	if command.Mode == sunbeam.CommandModeTTY {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("command %s requires a terminal", command.Name))
+		return
+	}
+
+	payload, err := s.payload(alias, command, req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if command.Mode == sunbeam.CommandModeSilent {
+		if _, err := extension.OutputContext(ctx, payload); err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	// the items of a stream command are collected in a list
+	if command.Stream {
+		items := make([]json.RawMessage, 0)
+		if err := extension.StreamContext(ctx, payload, func(line []byte) error {
+			if err := schemas.ValidateListItem(line); err != nil {
+				return fmt.Errorf("invalid list item: %w", err)
+			}
+
+			items = append(items, json.RawMessage(line))
+			return nil
+		}); err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]any{"items": items})
+		return
+	}
+
+	output, err := extension.OutputContext(ctx, payload)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := schemas.ValidateOutput(command.Mode, output); err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("invalid output: %w", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(output)
+}
+
+// serveWebsocket runs the command for each request received, cancelling the previous run.
+// Lists are run again after their autoRefreshSeconds, until the next request.
+func (s *Server) serveWebsocket(conn *websocket.Conn, alias string, extension extensions.Extension, command sunbeam.CommandSpec) {
+	defer conn.Close()
+
+	var mu sync.Mutex
+	send := func(event Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		_ = websocket.JSON.Send(conn, event)
+	}
+
+	// cancelling the context stops the last run when the connection is closed
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stop := func() {}
+	for {
+		var req Request
+		if err := websocket.JSON.Receive(conn, &req); err != nil {
+			return
+		}
+
+		stop()
+		runCtx, runCancel := context.WithCancel(ctx)
+		stop = runCancel
+		go s.watch(runCtx, send, alias, extension, command, req)
+	}
+}
+
+// watch runs the command and sends its output, until the context is cancelled
+func (s *Server) watch(ctx context.Context, send func(Event), alias string, extension extensions.Extension, command sunbeam.CommandSpec, req Request) {
+	sendError := func(err error) {
+		if ctx.Err() != nil {
+			return
+		}
+
+		send(Event{Id: req.Id, Type: EventTypeError, Error: toError(err)})
+	}
+
+	switch command.Mode {
+	case sunbeam.CommandModeSearch, sunbeam.CommandModeFilter, sunbeam.CommandModeDetail, sunbeam.CommandModeGrid:
+	default:
+		sendError(fmt.Errorf("commands with mode %s can not be watched", command.Mode))
+		return
+	}
+
+	payload, err := s.payload(alias, command, req)
+	if err != nil {
+		sendError(err)
+		return
+	}
+
+	if command.Stream {
+		if err := extension.StreamContext(ctx, payload, func(line []byte) error {
+			if err := schemas.ValidateListItem(line); err != nil {
+				return fmt.Errorf("invalid list item: %w", err)
+			}
+
+			send(Event{Id: req.Id, Type: EventTypeItem, Data: json.RawMessage(line)})
+			return nil
+		}); err != nil {
+			sendError(err)
+			return
+		}
+
+		send(Event{Id: req.Id, Type: EventTypeDone})
+		return
+	}
+
+	for {
+		output, err := extension.OutputContext(ctx, payload)
+		if err != nil {
+			sendError(err)
+			return
+		}
+
+		if err := schemas.ValidateOutput(command.Mode, output); err != nil {
+			sendError(fmt.Errorf("invalid output: %w", err))
+			return
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+		send(Event{Id: req.Id, Type: EventTypeOutput, Data: json.RawMessage(output)})
+
+		var list sunbeam.List
+		if err := json.Unmarshal(output, &list); err != nil || list.AutoRefreshSeconds <= 0 {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Duration(list.AutoRefreshSeconds) * time.Second):
+		}
+	}
+}
+
+func toError(err error) *sunbeam.Error {
+	var structured *sunbeam.Error
+	if errors.As(err, &structured) {
+		return structured
+	}
+
+	return &sunbeam.Error{
+		Title:   "Command failed",
+		Message: err.Error(),
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(v)
+}
+
+// writeError sends the error using the format of the errors reported by extensions
+func writeError(w http.ResponseWriter, status int, err error) {
+	var structured *sunbeam.Error
+	if !errors.As(err, &structured) {
+		structured = &sunbeam.Error{
+			Title:   http.StatusText(status),
+			Message: err.Error(),
+		}
+	}
+
+	writeJSON(w, status, structured)
+}
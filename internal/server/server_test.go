@@ -0,0 +1,151 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pomdtr/sunbeam/internal/extensions"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+func TestServerAuthorization(t *testing.T) {
+	s := New(extensions.ExtensionMap{}, "secret", func(alias string) (map[string]any, error) {
+		return nil, nil
+	})
+	s.AllowedOrigins = []string{"https://example.com"}
+
+	tests := []struct {
+		name     string
+		method   string
+		target   string
+		headers  map[string]string
+		expected int
+	}{
+		{
+			name:     "missing token",
+			method:   http.MethodGet,
+			target:   "/extensions",
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name:     "invalid token",
+			method:   http.MethodGet,
+			target:   "/extensions",
+			headers:  map[string]string{"Authorization": "Bearer public"},
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name:     "header token",
+			method:   http.MethodGet,
+			target:   "/extensions",
+			headers:  map[string]string{"Authorization": "Bearer secret"},
+			expected: http.StatusOK,
+		},
+		{
+			name:     "query token",
+			method:   http.MethodGet,
+			target:   "/extensions?token=secret",
+			expected: http.StatusOK,
+		},
+		{
+			name:     "allowed origin",
+			method:   http.MethodGet,
+			target:   "/extensions",
+			headers:  map[string]string{"Authorization": "Bearer secret", "Origin": "https://example.com"},
+			expected: http.StatusOK,
+		},
+		{
+			name:     "forbidden origin",
+			method:   http.MethodGet,
+			target:   "/extensions",
+			headers:  map[string]string{"Authorization": "Bearer secret", "Origin": "https://evil.com"},
+			expected: http.StatusForbidden,
+		},
+		{
+			name:     "own origin",
+			method:   http.MethodGet,
+			target:   "/extensions",
+			headers:  map[string]string{"Authorization": "Bearer secret", "Origin": "http://localhost:9999"},
+			expected: http.StatusOK,
+		},
+		{
+			name:     "other local origin",
+			method:   http.MethodGet,
+			target:   "/extensions",
+			headers:  map[string]string{"Authorization": "Bearer secret", "Origin": "http://localhost:3000"},
+			expected: http.StatusForbidden,
+		},
+		{
+			name:     "preflight",
+			method:   http.MethodOptions,
+			target:   "/extensions",
+			headers:  map[string]string{"Origin": "https://example.com"},
+			expected: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			req = req.WithContext(context.WithValue(req.Context(), http.LocalAddrContextKey, &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 9999}))
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+			if rec.Code != tt.expected {
+				t.Errorf("expected status %d, got %d", tt.expected, rec.Code)
+			}
+		})
+	}
+
+	t.Run("empty token", func(t *testing.T) {
+		s := New(extensions.ExtensionMap{}, "", nil)
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/extensions", nil))
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("expected status %d, got %d", http.StatusUnauthorized, rec.Code)
+		}
+	})
+}
+
+func TestServerEmptyBody(t *testing.T) {
+	entrypoint := filepath.Join(t.TempDir(), "ext.sh")
+	if err := os.WriteFile(entrypoint, []byte("#!/bin/sh\necho '{\"text\": \"hello\"}'\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	extensionMap := extensions.ExtensionMap{
+		"test": {
+			Entrypoint: entrypoint,
+			Manifest: sunbeam.Manifest{
+				Title:    "Test",
+				Commands: []sunbeam.CommandSpec{{Name: "hello", Title: "Hello", Mode: sunbeam.CommandModeDetail}},
+			},
+		},
+	}
+
+	s := New(extensionMap, "secret", func(alias string) (map[string]any, error) {
+		return nil, nil
+	})
+
+	for _, contentLength := range []int64{0, -1} {
+		req := httptest.NewRequest(http.MethodPost, "/extensions/test/hello", strings.NewReader(""))
+		req.Header.Set("Authorization", "Bearer secret")
+		// a chunked request does not declare its length
+		req.ContentLength = contentLength
+
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Errorf("expected status %d with content length %d, got %d: %s", http.StatusOK, contentLength, rec.Code, rec.Body.String())
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pomdtr/sunbeam/internal/utils"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+// ExecCmd returns the shell command of an exec action, resolving its working directory
+func ExecCmd(action *sunbeam.ExecAction) (*exec.Cmd, error) {
+	cmd := exec.Command("sh", "-c", action.Command)
+	cmd.Dir = action.Dir
+	if strings.HasPrefix(cmd.Dir, "~") {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+
+		cmd.Dir = filepath.Join(homeDir, strings.TrimPrefix(cmd.Dir, "~"))
+	}
+
+	if !filepath.IsAbs(cmd.Dir) {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+
+		cmd.Dir = filepath.Join(wd, cmd.Dir)
+	}
+
+	return cmd, nil
+}
+
+// EditCmd returns the command opening the file of an edit action in the editor of the user
+func EditCmd(action *sunbeam.EditAction) *exec.Cmd {
+	return exec.Command("sunbeam", "edit", action.Path)
+}
+
+// OpenTarget opens the url or the path of an open action with the default application
+func OpenTarget(action *sunbeam.OpenAction) error {
+	if action.Url != "" {
+		return utils.Open(action.Url)
+	}
+
+	if action.Path != "" {
+		return utils.Open(fmt.Sprintf("file://%s", action.Path))
+	}
+
+	return fmt.Errorf("invalid target")
+}
@@ -0,0 +1,146 @@
+package tui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+// BatchMsg is sent instead of an action when it is triggered while several items are selected.
+// It contains the matching action of each selected item.
+type BatchMsg struct {
+	Actions []sunbeam.Action
+}
+
+func isBatchable(action sunbeam.Action) bool {
+	switch action.Type {
+	case sunbeam.ActionTypeCopy, sunbeam.ActionTypeOpen:
+		return true
+	case sunbeam.ActionTypeRun:
+		return action.Run.Batch
+	default:
+		return false
+	}
+}
+
+// findAction returns the action matching the given one in a list of actions
+func findAction(actions []sunbeam.Action, target sunbeam.Action) (sunbeam.Action, bool) {
+	for _, action := range actions {
+		if action.Type != target.Type || action.Title != target.Title {
+			continue
+		}
+
+		if action.Type == sunbeam.ActionTypeRun && (action.Run.Command != target.Run.Command || action.Run.Extension != target.Run.Extension) {
+			continue
+		}
+
+		return action, true
+	}
+
+	return sunbeam.Action{}, false
+}
+
+// batchActions returns the actions of the first item that are available on all the items
+func batchActions(items []FilterItem) []sunbeam.Action {
+	if len(items) == 0 {
+		return nil
+	}
+
+	var actions []sunbeam.Action
+	for _, action := range items[0].(ListItem).Actions {
+		if !isBatchable(action) {
+			continue
+		}
+
+		available := true
+		for _, item := range items[1:] {
+			if match, ok := findAction(item.(ListItem).Actions, action); !ok || !isBatchable(match) {
+				available = false
+				break
+			}
+		}
+
+		if available {
+			actions = append(actions, action)
+		}
+	}
+
+	return actions
+}
+
+// batchCmd converts the action returned by cmd to a BatchMsg targeting all the items
+func batchCmd(cmd tea.Cmd, items []FilterItem) tea.Cmd {
+	return func() tea.Msg {
+		msg := cmd()
+		action, ok := msg.(sunbeam.Action)
+		if !ok {
+			return msg
+		}
+
+		actions := make([]sunbeam.Action, 0, len(items))
+		for _, item := range items {
+			if match, ok := findAction(item.(ListItem).Actions, action); ok {
+				actions = append(actions, match)
+			}
+		}
+
+		return BatchMsg{Actions: actions}
+	}
+}
+
+// MergeActions combines the actions of a batch into a single action.
+// Copy actions are joined line by line, and the params of run actions are grouped in arrays,
+// with a nil value for the items missing a param so that the indexes of every param match.
+// Open actions are chained in a sequence, which is confirmed once.
+func MergeActions(actions []sunbeam.Action) (sunbeam.Action, bool) {
+	if len(actions) == 0 {
+		return sunbeam.Action{}, false
+	}
+
+	merged := actions[0]
+	switch merged.Type {
+	case sunbeam.ActionTypeCopy:
+		texts := make([]string, len(actions))
+		for i, action := range actions {
+			texts[i] = action.Copy.Text
+		}
+
+		copyAction := *merged.Copy
+		copyAction.Text = strings.Join(texts, "\n")
+		merged.Copy = &copyAction
+		return merged, true
+	case sunbeam.ActionTypeRun:
+		params := make(map[string]any)
+		for _, action := range actions {
+			for k := range action.Run.Params {
+				params[k] = make([]any, 0, len(actions))
+			}
+		}
+
+		for _, action := range actions {
+			for k, values := range params {
+				params[k] = append(values.([]any), action.Run.Params[k])
+			}
+		}
+
+		runAction := *merged.Run
+		runAction.Params = params
+		runAction.Batch = false
+		merged.Run = &runAction
+		return merged, true
+	case sunbeam.ActionTypeOpen:
+		sequence := make([]sunbeam.Action, len(actions))
+		for i, action := range actions {
+			action.Confirm = nil
+			sequence[i] = action
+		}
+
+		merged.Type = sunbeam.ActionTypeSequence
+		merged.Open = nil
+		merged.Sequence = &sunbeam.SequenceAction{Actions: sequence}
+		return merged, true
+	default:
+		return sunbeam.Action{}, false
+	}
+}
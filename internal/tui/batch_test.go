@@ -0,0 +1,78 @@
+package tui
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+func TestMergeActions(t *testing.T) {
+	confirm := &sunbeam.Confirm{Message: "Open all?"}
+
+	tests := []struct {
+		name     string
+		actions  []sunbeam.Action
+		expected sunbeam.Action
+		ok       bool
+	}{
+		{
+			name: "empty",
+			ok:   false,
+		},
+		{
+			name: "copy",
+			actions: []sunbeam.Action{
+				{Title: "Copy", Type: sunbeam.ActionTypeCopy, Copy: &sunbeam.CopyAction{Text: "a", Exit: true}},
+				{Title: "Copy", Type: sunbeam.ActionTypeCopy, Copy: &sunbeam.CopyAction{Text: "b"}},
+			},
+			expected: sunbeam.Action{Title: "Copy", Type: sunbeam.ActionTypeCopy, Copy: &sunbeam.CopyAction{Text: "a\nb", Exit: true}},
+			ok:       true,
+		},
+		{
+			name: "run",
+			actions: []sunbeam.Action{
+				{Type: sunbeam.ActionTypeRun, Run: &sunbeam.RunAction{Command: "delete", Batch: true, Params: map[string]any{"id": "a", "force": true}}},
+				{Type: sunbeam.ActionTypeRun, Run: &sunbeam.RunAction{Command: "delete", Batch: true, Params: map[string]any{"id": "b"}}},
+				{Type: sunbeam.ActionTypeRun, Run: &sunbeam.RunAction{Command: "delete", Batch: true, Params: map[string]any{"force": false}}},
+			},
+			expected: sunbeam.Action{Type: sunbeam.ActionTypeRun, Run: &sunbeam.RunAction{Command: "delete", Params: map[string]any{
+				"id":    []any{"a", "b", nil},
+				"force": []any{true, nil, false},
+			}}},
+			ok: true,
+		},
+		{
+			name: "open",
+			actions: []sunbeam.Action{
+				{Title: "Open", Type: sunbeam.ActionTypeOpen, Confirm: confirm, Open: &sunbeam.OpenAction{Url: "https://a.com"}},
+				{Title: "Open", Type: sunbeam.ActionTypeOpen, Confirm: confirm, Open: &sunbeam.OpenAction{Url: "https://b.com"}},
+			},
+			expected: sunbeam.Action{Title: "Open", Type: sunbeam.ActionTypeSequence, Confirm: confirm, Sequence: &sunbeam.SequenceAction{Actions: []sunbeam.Action{
+				{Title: "Open", Type: sunbeam.ActionTypeOpen, Open: &sunbeam.OpenAction{Url: "https://a.com"}},
+				{Title: "Open", Type: sunbeam.ActionTypeOpen, Open: &sunbeam.OpenAction{Url: "https://b.com"}},
+			}}},
+			ok: true,
+		},
+		{
+			name: "unsupported",
+			actions: []sunbeam.Action{
+				{Type: sunbeam.ActionTypeReload},
+			},
+			ok: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, ok := MergeActions(tt.actions)
+			if ok != tt.ok {
+				t.Fatalf("expected ok to be %t, got %t", tt.ok, ok)
+			}
+
+			if ok && !reflect.DeepEqual(merged, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, merged)
+			}
+		})
+	}
+}
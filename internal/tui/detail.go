@@ -27,6 +27,8 @@ type Detail struct {
 	input     textinput.Model
 
 	text          string
+	metadata      []sunbeam.Metadata
+	actions       []sunbeam.Action
 	width, height int
 
 	Style    lipgloss.Style
@@ -68,6 +70,7 @@ func NewDetail(text string, actions ...sunbeam.Action) *Detail {
 		viewport:  viewport,
 		statusBar: statusBar,
 		text:      text,
+		actions:   actions,
 	}
 
 	_ = d.RefreshContent()
@@ -100,9 +103,30 @@ func (d *Detail) SetIsLoading(isLoading bool) tea.Cmd {
 	return nil
 }
 
+func (d *Detail) SetStatus(status string) {
+	d.statusBar.SetStatus(status)
+}
+
+// SetMetadata sets the metadata displayed below the content, and the actions to copy them
+func (d *Detail) SetMetadata(metadata ...sunbeam.Metadata) {
+	d.metadata = metadata
+	d.statusBar.SetActions(append(d.actions[:len(d.actions):len(d.actions)], metadataActions(metadata)...)...)
+	_ = d.RefreshContent()
+}
+
 func (c *Detail) Update(msg tea.Msg) (Page, tea.Cmd) {
 	switch msg := msg.(type) {
+	case imageLoadedMsg:
+		_ = c.RefreshContent()
+		return c, nil
 	case tea.KeyMsg:
+		// the key presses answer the confirmation prompt
+		if c.statusBar.Confirming() {
+			statusBar, cmd := c.statusBar.Update(msg)
+			c.statusBar = statusBar
+			return c, cmd
+		}
+
 		switch msg.String() {
 		case "tab":
 			if c.statusBar.expanded {
@@ -166,7 +190,7 @@ func (c *Detail) RefreshContent() error {
 			return err
 		}
 
-		content, err = render.Render(c.text)
+		content, err = renderMarkdown(render, c.text, c.width, max(c.height-4, 1))
 		if err != nil {
 			return err
 		}
@@ -175,6 +199,14 @@ func (c *Detail) RefreshContent() error {
 		content = lipgloss.NewStyle().Padding(0, 2).Render(content)
 	}
 
+	if metadata := renderMetadata(c.metadata, c.width); metadata != "" {
+		if c.text != "" {
+			content = lipgloss.JoinVertical(lipgloss.Left, content, "", metadata)
+		} else {
+			content = metadata
+		}
+	}
+
 	c.viewport.SetContent(content)
 	return nil
 }
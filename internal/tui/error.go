@@ -1,9 +1,21 @@
 package tui
 
-import "github.com/pomdtr/sunbeam/pkg/sunbeam"
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
 
 func NewErrorPage(err error, additionalActions ...sunbeam.Action) *Detail {
 	var actions []sunbeam.Action
+
+	var structured *sunbeam.Error
+	if errors.As(err, &structured) {
+		actions = append(actions, structured.Actions...)
+	}
+
 	actions = append(actions, sunbeam.Action{
 		Title: "Copy error",
 		Type:  sunbeam.ActionTypeCopy,
@@ -14,7 +26,26 @@ func NewErrorPage(err error, additionalActions ...sunbeam.Action) *Detail {
 	})
 	actions = append(actions, additionalActions...)
 
-	detail := NewDetail(err.Error(), actions...)
+	if structured == nil {
+		return NewDetail(err.Error(), actions...)
+	}
 
+	detail := NewDetail(renderError(structured), actions...)
+	detail.Markdown = true
 	return detail
 }
+
+func renderError(err *sunbeam.Error) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s\n", err.Title)
+
+	if err.Message != "" {
+		fmt.Fprintf(&b, "\n%s\n", err.Message)
+	}
+
+	if err.Hint != "" {
+		fmt.Fprintf(&b, "\n> %s\n", err.Hint)
+	}
+
+	return b.String()
+}
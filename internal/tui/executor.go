@@ -0,0 +1,534 @@
+package tui
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pomdtr/sunbeam/internal/extensions"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+// ActionExecutor runs the actions triggered from a page, the same way for every page.
+// The hooks adapt it to the page: where the extensions come from, how forms and reloads are displayed.
+type ActionExecutor struct {
+	// Extension returns the extension targeted by a run or config action, with its preferences.
+	// The alias is empty when the action targets the extension of the page.
+	Extension func(alias string) (extensions.Extension, map[string]any, error)
+	// SavePreferences updates the preferences of an extension with the values submitted through a form
+	SavePreferences func(alias string, extension extensions.Extension, values map[string]any) error
+	// Source returns the extension which returned the actions, exec, open and edit actions are restricted to its permissions.
+	// It is nil when the actions come from the config.
+	Source func() extensions.Extension
+	// ShowForm displays a form asking for the missing inputs of an action
+	ShowForm func(form *Form) tea.Cmd
+	// HideForm is called once the form is submitted
+	HideForm func()
+	// Reload runs the command of the page again, with the given params
+	Reload func(params map[string]any) tea.Cmd
+	// Focus is called when the page is displayed again, after an interactive process exits
+	Focus func() tea.Cmd
+	// Confirm asks the user to confirm an action, run continues with the action once confirmed.
+	// If nil, the prompt is shown in the status bar of the current page.
+	Confirm func(action sunbeam.Action, run tea.Cmd) tea.Cmd
+	// Push displays the output of a command with a view mode.
+	// If nil, a runner is pushed on top of the current page.
+	Push func(alias string, extension extensions.Extension, input sunbeam.Payload) tea.Cmd
+	// ExecProcess runs an interactive process, fn is called once it exits.
+	// If nil, the process takes over the terminal until it exits.
+	ExecProcess func(cmd *exec.Cmd, fn tea.ExecCallback) tea.Cmd
+
+	// next are the actions of the sequence left to run once the current one completes
+	next []sunbeam.Action
+}
+
+// nextActionsMsg runs the actions left in a sequence, once the previous one completed.
+// It is sent to the current page, which can be a page pushed by the previous action,
+// so it carries the executor which started the sequence.
+type nextActionsMsg struct {
+	executor ActionExecutor
+	actions  []sunbeam.Action
+}
+
+// resume runs the actions with the executor which started the sequence, so that they keep targeting its extension and params.
+// Forms are shown on the current page, which is the one displayed.
+func (msg nextActionsMsg) resume(current ActionExecutor) tea.Cmd {
+	e := msg.executor
+	e.ShowForm, e.HideForm, e.Focus = current.ShowForm, current.HideForm, current.Focus
+	return e.sequence(msg.actions)
+}
+
+// confirmMsg asks the user to confirm an action, run is returned once it is confirmed
+type confirmMsg struct {
+	action sunbeam.Action
+	run    tea.Cmd
+}
+
+// thenMsg is the message of an action of a sequence which completed successfully.
+// The paginator handles the message first, then runs next to continue the sequence.
+// Unlike tea.Sequence, it can be handled outside of a tea.Program.
+type thenMsg struct {
+	msg  tea.Msg
+	next tea.Cmd
+}
+
+// commandErrorMsg is the failure of a command run by an action.
+// Unlike other errors, the paginator shows it in a new page, on top of the current one.
+type commandErrorMsg struct {
+	err error
+}
+
+// Run executes the action outside of a tea.Program, until it completes or fails.
+// The messages which are not handled by the executor are passed to handle.
+func (e ActionExecutor) Run(action sunbeam.Action, handle func(msg tea.Msg) error) error {
+	cmds := []tea.Cmd{e.Execute(action)}
+	for len(cmds) > 0 {
+		cmd := cmds[0]
+		cmds = cmds[1:]
+		if cmd == nil {
+			continue
+		}
+
+		switch msg := cmd().(type) {
+		case nil:
+			continue
+		case error:
+			return msg
+		case commandErrorMsg:
+			return msg.err
+		case tea.BatchMsg:
+			cmds = append(cmds, msg...)
+		case thenMsg:
+			then := msg
+			cmds = append(cmds, func() tea.Msg { return then.msg }, then.next)
+		case nextActionsMsg:
+			cmds = append(cmds, msg.resume(e))
+		default:
+			if err := handle(msg); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (e ActionExecutor) Execute(action sunbeam.Action) tea.Cmd {
+	// every action is confirmed before it runs, including the ones of a sequence
+	if action.Confirm != nil {
+		confirmed := action
+		confirmed.Confirm = nil
+		msg := nextActionsMsg{executor: e, actions: append([]sunbeam.Action{confirmed}, e.next...)}
+		run := func() tea.Msg {
+			return msg
+		}
+
+		if e.Confirm != nil {
+			return e.Confirm(action, run)
+		}
+
+		return func() tea.Msg {
+			return confirmMsg{action: action, run: run}
+		}
+	}
+
+	switch action.Type {
+	case sunbeam.ActionTypeRun:
+		return e.run(action)
+	case sunbeam.ActionTypeConfig:
+		var alias string
+		if action.Config != nil {
+			alias = action.Config.Extension
+		}
+
+		return e.configure(alias)
+	case sunbeam.ActionTypeCopy:
+		return func() tea.Msg {
+			if err := clipboard.WriteAll(action.Copy.Text); err != nil {
+				return err
+			}
+
+			if action.Copy.Exit {
+				return e.done(ExitMsg{})
+			}
+
+			return e.done(ShowNotificationMsg{"Copied!"})
+		}
+	case sunbeam.ActionTypeOpen:
+		if err := e.checkOpen(action.Open); err != nil {
+			return errorCmd(err)
+		}
+
+		return func() tea.Msg {
+			if err := OpenTarget(action.Open); err != nil {
+				return err
+			}
+
+			return e.done(ExitMsg{})
+		}
+	case sunbeam.ActionTypeEdit:
+		if e.Source != nil {
+			if err := e.Source().CheckPath(action.Edit.Path); err != nil {
+				return errorCmd(err)
+			}
+		}
+
+		return e.execProcess(EditCmd(action.Edit), func(err error) tea.Msg {
+			if err != nil {
+				return err
+			}
+
+			if action.Edit.Reload {
+				e.Focus()
+				return e.reload()
+			}
+
+			if action.Edit.Exit {
+				return e.done(ExitMsg{})
+			}
+
+			return e.done(e.Focus())
+		})
+	case sunbeam.ActionTypeExec:
+		return e.exec(action.Exec)
+	case sunbeam.ActionTypeExit:
+		return func() tea.Msg {
+			return e.done(ExitMsg{})
+		}
+	case sunbeam.ActionTypeReload:
+		var params map[string]any
+		if action.Reload != nil {
+			params = action.Reload.Params
+		}
+
+		// the page is already reloading, the next actions do not need to wait for the output
+		return tea.Batch(e.Reload(params), e.nextCmd())
+	case sunbeam.ActionTypeSequence:
+		actions := action.Sequence.Actions[:len(action.Sequence.Actions):len(action.Sequence.Actions)]
+		return e.sequence(append(actions, e.next...))
+	default:
+		return nil
+	}
+}
+
+// sequence runs the first action, the next ones are run once it completes successfully
+func (e ActionExecutor) sequence(actions []sunbeam.Action) tea.Cmd {
+	if len(actions) == 0 {
+		return nil
+	}
+
+	e.next = actions[1:]
+	return e.Execute(actions[0])
+}
+
+// done returns the message of an action which completed successfully, followed by the next actions of the sequence.
+// Failures are returned as is, which stops the sequence.
+func (e ActionExecutor) done(msg tea.Msg) tea.Msg {
+	if len(e.next) == 0 {
+		return msg
+	}
+
+	// sunbeam exits once all the actions of the sequence are done
+	if _, ok := msg.(ExitMsg); ok {
+		if e.next[len(e.next)-1].Type != sunbeam.ActionTypeExit {
+			e.next = append(e.next[:len(e.next):len(e.next)], sunbeam.Action{Type: sunbeam.ActionTypeExit})
+		}
+
+		return nextActionsMsg{executor: e, actions: e.next}
+	}
+
+	switch msg := msg.(type) {
+	case nil:
+		return nextActionsMsg{executor: e, actions: e.next}
+	case tea.Cmd:
+		return tea.BatchMsg{msg, e.nextCmd()}
+	default:
+		return thenMsg{msg: msg, next: e.nextCmd()}
+	}
+}
+
+// then runs the next actions of the sequence after the message of cmd is handled
+func (e ActionExecutor) then(cmd tea.Cmd) tea.Cmd {
+	if len(e.next) == 0 {
+		return cmd
+	}
+
+	return func() tea.Msg {
+		return e.done(cmd())
+	}
+}
+
+func (e ActionExecutor) nextCmd() tea.Cmd {
+	if len(e.next) == 0 {
+		return nil
+	}
+
+	msg := nextActionsMsg{executor: e, actions: e.next}
+	return func() tea.Msg {
+		return msg
+	}
+}
+
+// reload reloads the page which started the action, followed by the rest of the sequence.
+// Unlike a ReloadMsg, it does not target the page displayed, which can be a page pushed by a previous action.
+func (e ActionExecutor) reload() tea.Msg {
+	return nextActionsMsg{executor: e, actions: append([]sunbeam.Action{{Type: sunbeam.ActionTypeReload}}, e.next...)}
+}
+
+// retry runs the action again once its missing inputs are filled, followed by the rest of the sequence
+func (e ActionExecutor) retry(action sunbeam.Action) tea.Msg {
+	return nextActionsMsg{executor: e, actions: append([]sunbeam.Action{action}, e.next...)}
+}
+
+func (e ActionExecutor) run(action sunbeam.Action) tea.Cmd {
+	alias := action.Run.Extension
+	extension, preferences, err := e.Extension(alias)
+	if err != nil {
+		return errorCmd(err)
+	}
+
+	if missing := FindMissingPreferences(extension.Manifest.Preferences, preferences); hasRequired(missing) {
+		form := NewForm(func(values map[string]any) tea.Msg {
+			e.HideForm()
+			if err := e.SavePreferences(alias, extension, values); err != nil {
+				return err
+			}
+
+			return e.retry(action)
+		}, missing...)
+		form.OptionsProvider = func(input sunbeam.Input) ([]sunbeam.ListItem, error) {
+			return extension.Options(input, preferences, nil)
+		}
+
+		return e.ShowForm(form)
+	}
+
+	command, ok := extension.Command(action.Run.Command)
+	if !ok {
+		return errorCmd(fmt.Errorf("command %s not found", action.Run.Command))
+	}
+
+	if missing := FindMissingInputs(command.Params, action.Run.Params); hasRequired(missing) {
+		form := NewForm(func(values map[string]any) tea.Msg {
+			e.HideForm()
+
+			params := make(map[string]any)
+			for k, v := range action.Run.Params {
+				params[k] = v
+			}
+
+			for k, v := range values {
+				params[k] = v
+			}
+
+			props := *action.Run
+			props.Params = params
+			action.Run = &props
+
+			return e.retry(action)
+		}, missing...)
+		form.OptionsProvider = func(input sunbeam.Input) ([]sunbeam.ListItem, error) {
+			return extension.Options(input, preferences, action.Run.Params)
+		}
+
+		return e.ShowForm(form)
+	}
+
+	input := sunbeam.Payload{
+		Command:     command.Name,
+		Preferences: preferences,
+		Params:      make(map[string]any),
+	}
+
+	for k, v := range action.Run.Params {
+		input.Params[k] = v
+	}
+
+	switch command.Mode {
+	case sunbeam.CommandModeSearch, sunbeam.CommandModeFilter, sunbeam.CommandModeDetail, sunbeam.CommandModeGrid:
+		return e.then(e.push(alias, extension, input))
+	case sunbeam.CommandModeSilent:
+		return func() tea.Msg {
+			output, err := extension.Output(input)
+			if err != nil {
+				return commandErrorMsg{err}
+			}
+
+			if action.Run.Reload {
+				return e.reload()
+			}
+
+			if action.Run.Exit {
+				return e.done(ExitMsg{})
+			}
+
+			return e.done(notifyOutput(output))
+		}
+	case sunbeam.CommandModeTTY:
+		cmd, err := extension.Cmd(input)
+		if err != nil {
+			return errorCmd(err)
+		}
+
+		return e.execProcess(cmd, func(err error) tea.Msg {
+			if err != nil {
+				return commandErrorMsg{err}
+			}
+
+			if action.Run.Reload {
+				e.Focus()
+				return e.reload()
+			}
+
+			if action.Run.Exit {
+				return e.done(ExitMsg{})
+			}
+
+			return e.done(e.Focus())
+		})
+	default:
+		return errorCmd(fmt.Errorf("unknown command mode: %s", command.Mode))
+	}
+}
+
+// configure shows a form to edit the preferences of the extension
+func (e ActionExecutor) configure(alias string) tea.Cmd {
+	extension, preferences, err := e.Extension(alias)
+	if err != nil {
+		return errorCmd(err)
+	}
+
+	inputs := make([]sunbeam.Input, 0)
+	for _, input := range extension.Manifest.Preferences {
+		if preference, ok := preferences[input.Name]; ok {
+			input.Default = preference
+		}
+		input.Optional = false
+		inputs = append(inputs, input)
+	}
+
+	if len(inputs) == 0 {
+		return func() tea.Msg {
+			return e.done(ShowNotificationMsg{"No preferences to configure"})
+		}
+	}
+
+	form := NewForm(func(values map[string]any) tea.Msg {
+		e.HideForm()
+		if err := e.SavePreferences(alias, extension, values); err != nil {
+			return err
+		}
+
+		return e.reload()
+	}, inputs...)
+	form.OptionsProvider = func(input sunbeam.Input) ([]sunbeam.ListItem, error) {
+		return extension.Options(input, preferences, nil)
+	}
+
+	return e.ShowForm(form)
+}
+
+func (e ActionExecutor) exec(action *sunbeam.ExecAction) tea.Cmd {
+	cmd, err := ExecCmd(action)
+	if err != nil {
+		return errorCmd(err)
+	}
+
+	// the commands of an extension can't escape its sandbox through exec actions
+	if e.Source != nil {
+		if err := e.Source().Restrict(cmd); err != nil {
+			return errorCmd(err)
+		}
+	}
+
+	if !action.Interactive {
+		return func() tea.Msg {
+			output, err := cmd.Output()
+			if err != nil {
+				return err
+			}
+
+			if action.Exit {
+				return e.done(ExitMsg{})
+			}
+
+			return e.done(notifyOutput(output))
+		}
+	}
+
+	return e.execProcess(cmd, func(err error) tea.Msg {
+		if err != nil {
+			return err
+		}
+
+		if action.Exit {
+			return e.done(ExitMsg{})
+		}
+
+		return e.done(e.Focus())
+	})
+}
+
+func (e ActionExecutor) push(alias string, extension extensions.Extension, input sunbeam.Payload) tea.Cmd {
+	if e.Push != nil {
+		return e.Push(alias, extension, input)
+	}
+
+	runner := NewRunner(extension, input)
+	runner.SavePreferences = func(values map[string]any) error {
+		return e.SavePreferences(alias, extension, values)
+	}
+
+	return PushPageCmd(runner)
+}
+
+func (e ActionExecutor) execProcess(cmd *exec.Cmd, fn tea.ExecCallback) tea.Cmd {
+	if e.ExecProcess != nil {
+		return e.ExecProcess(cmd, fn)
+	}
+
+	return tea.ExecProcess(cmd, fn)
+}
+
+// checkOpen returns an error if the extension which returned the action is not allowed to open its target
+func (e ActionExecutor) checkOpen(action *sunbeam.OpenAction) error {
+	if e.Source == nil {
+		return nil
+	}
+
+	if action.Url != "" {
+		return e.Source().CheckURL(action.Url)
+	}
+
+	return e.Source().CheckPath(action.Path)
+}
+
+// notifyOutput shows the last line printed by a command in the status bar
+func notifyOutput(output []byte) tea.Msg {
+	output = bytes.Trim(output, "\n")
+	if len(output) == 0 {
+		return nil
+	}
+
+	rows := strings.Split(string(output), "\n")
+	return ShowNotificationMsg{rows[len(rows)-1]}
+}
+
+func hasRequired(inputs []sunbeam.Input) bool {
+	for _, input := range inputs {
+		if !input.Optional {
+			return true
+		}
+	}
+
+	return false
+}
+
+func errorCmd(err error) tea.Cmd {
+	return func() tea.Msg {
+		return err
+	}
+}
@@ -0,0 +1,366 @@
+package tui
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pomdtr/sunbeam/internal/config"
+	"github.com/pomdtr/sunbeam/internal/extensions"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+// testManifest declares a silent command echoing its payload
+const testManifest = `{
+	"title": "Test",
+	"commands": [
+		{"name": "echo", "title": "Echo", "mode": "silent", "params": [{"name": "text", "title": "Text", "type": "string"}]}
+	]
+}`
+
+// writeTestExtension writes an extension printing its manifest, or the payload it receives
+func writeTestExtension(t *testing.T, dir string) string {
+	t.Helper()
+
+	entrypoint := filepath.Join(dir, "test.sh")
+	script := "#!/bin/sh\nif [ $# -eq 0 ]; then\ncat <<'EOF'\n" + testManifest + "\nEOF\nexit 0\nfi\necho \"$1\"\n"
+	if err := os.WriteFile(entrypoint, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	return entrypoint
+}
+
+func newTestExtension(t *testing.T) extensions.Extension {
+	t.Helper()
+
+	var manifest sunbeam.Manifest
+	if err := json.Unmarshal([]byte(testManifest), &manifest); err != nil {
+		t.Fatal(err)
+	}
+
+	return extensions.Extension{
+		Manifest:   manifest,
+		Entrypoint: writeTestExtension(t, t.TempDir()),
+	}
+}
+
+// testExecutor returns an executor targeting the extension, the forms it shows are sent to the channel
+func testExecutor(extension extensions.Extension, forms chan<- *Form) ActionExecutor {
+	return ActionExecutor{
+		Extension: func(alias string) (extensions.Extension, map[string]any, error) {
+			return extension, nil, nil
+		},
+		SavePreferences: func(string, extensions.Extension, map[string]any) error {
+			return nil
+		},
+		ShowForm: func(form *Form) tea.Cmd {
+			forms <- form
+			return nil
+		},
+		HideForm: func() {},
+		Reload: func(map[string]any) tea.Cmd {
+			return func() tea.Msg {
+				return ReloadMsg{}
+			}
+		},
+		Focus: func() tea.Cmd {
+			return nil
+		},
+	}
+}
+
+func runAction(command string, params map[string]any) sunbeam.Action {
+	return sunbeam.Action{Type: sunbeam.ActionTypeRun, Run: &sunbeam.RunAction{Command: command, Params: params}}
+}
+
+func TestExecutorRun(t *testing.T) {
+	executor := testExecutor(newTestExtension(t), nil)
+
+	msg := executor.Execute(runAction("echo", map[string]any{"text": "hello"}))()
+	notification, ok := msg.(ShowNotificationMsg)
+	if !ok {
+		t.Fatalf("expected a notification, got %#v", msg)
+	}
+
+	var payload sunbeam.Payload
+	if err := json.Unmarshal([]byte(notification.Title), &payload); err != nil {
+		t.Fatalf("expected the payload to be printed: %s", err)
+	}
+
+	if payload.Command != "echo" || payload.Params["text"] != "hello" {
+		t.Errorf("unexpected payload %+v", payload)
+	}
+
+	if msg := executor.Execute(runAction("missing", nil))(); !isError(msg) {
+		t.Errorf("expected an error for an unknown command, got %#v", msg)
+	}
+}
+
+func TestExecutorSequence(t *testing.T) {
+	executor := testExecutor(newTestExtension(t), nil)
+
+	var ran []string
+	sequence := sunbeam.Action{Type: sunbeam.ActionTypeSequence, Sequence: &sunbeam.SequenceAction{Actions: []sunbeam.Action{
+		runAction("echo", map[string]any{"text": "first"}),
+		{Type: sunbeam.ActionTypeReload},
+		runAction("echo", map[string]any{"text": "second"}),
+	}}}
+
+	// drain the commands the way the program would, the sequence continues through the page
+	cmds := []tea.Cmd{executor.Execute(sequence)}
+	for len(cmds) > 0 {
+		cmd := cmds[0]
+		cmds = cmds[1:]
+		if cmd == nil {
+			continue
+		}
+
+		switch msg := cmd().(type) {
+		case thenMsg:
+			cmds = append(cmds, func() tea.Msg { return msg.msg }, msg.next)
+		case tea.BatchMsg:
+			cmds = append(cmds, msg...)
+		case nextActionsMsg:
+			cmds = append(cmds, msg.resume(executor))
+		case ShowNotificationMsg:
+			var payload sunbeam.Payload
+			if err := json.Unmarshal([]byte(msg.Title), &payload); err != nil {
+				t.Fatal(err)
+			}
+			ran = append(ran, payload.Params["text"].(string))
+		case ReloadMsg:
+			ran = append(ran, "reload")
+		default:
+			t.Fatalf("unexpected message %#v", msg)
+		}
+	}
+
+	if strings.Join(ran, ",") != "first,reload,second" {
+		t.Errorf("unexpected order %v", ran)
+	}
+}
+
+func TestExecutorSequenceStopsOnError(t *testing.T) {
+	executor := testExecutor(newTestExtension(t), nil)
+
+	msg := executor.Execute(sunbeam.Action{Type: sunbeam.ActionTypeSequence, Sequence: &sunbeam.SequenceAction{Actions: []sunbeam.Action{
+		runAction("missing", nil),
+		runAction("echo", map[string]any{"text": "never"}),
+	}}})()
+
+	if !isError(msg) {
+		t.Errorf("expected the sequence to stop with an error, got %#v", msg)
+	}
+}
+
+func TestExecutorRetry(t *testing.T) {
+	forms := make(chan *Form, 1)
+	executor := testExecutor(newTestExtension(t), forms)
+
+	// the missing param is asked through a form
+	if cmd := executor.Execute(runAction("echo", nil)); cmd != nil {
+		t.Fatalf("expected no command, got %#v", cmd())
+	}
+
+	form := <-forms
+	msg := form.submitMsg(map[string]any{"text": "filled"})
+	retry, ok := msg.(nextActionsMsg)
+	if !ok || len(retry.actions) != 1 {
+		t.Fatalf("expected the action to be retried, got %#v", msg)
+	}
+
+	if params := retry.actions[0].Run.Params; params["text"] != "filled" {
+		t.Errorf("expected the submitted value to be used, got %v", params)
+	}
+
+	if msg, ok := retry.resume(executor)().(ShowNotificationMsg); !ok || !strings.Contains(msg.Title, "filled") {
+		t.Errorf("expected the retried action to run, got %#v", msg)
+	}
+
+	// in a sequence, the next actions are run after the retried one
+	executor.sequence([]sunbeam.Action{runAction("echo", nil), {Type: sunbeam.ActionTypeExit}})
+	form = <-forms
+	retry, ok = form.submitMsg(map[string]any{"text": "filled"}).(nextActionsMsg)
+	if !ok || len(retry.actions) != 2 || retry.actions[0].Run.Params["text"] != "filled" || retry.actions[1].Type != sunbeam.ActionTypeExit {
+		t.Errorf("expected the retried action followed by the rest of the sequence, got %#v", retry)
+	}
+}
+
+func TestExecutorResume(t *testing.T) {
+	var reloaded []string
+
+	originExtension := newTestExtension(t)
+	originForms := make(chan *Form, 1)
+	origin := testExecutor(originExtension, originForms)
+	origin.Reload = func(map[string]any) tea.Cmd {
+		reloaded = append(reloaded, "origin")
+		return nil
+	}
+
+	// the page pushed by the first action of the sequence
+	currentForms := make(chan *Form, 1)
+	current := testExecutor(newTestExtension(t), currentForms)
+	current.Reload = func(map[string]any) tea.Cmd {
+		reloaded = append(reloaded, "current")
+		return nil
+	}
+
+	next := nextActionsMsg{executor: origin, actions: []sunbeam.Action{
+		{Type: sunbeam.ActionTypeReload},
+		runAction("echo", nil),
+	}}
+
+	msg, ok := next.resume(current)().(nextActionsMsg)
+	if !ok {
+		t.Fatalf("expected the sequence to continue, got %#v", msg)
+	}
+
+	if strings.Join(reloaded, ",") != "origin" {
+		t.Errorf("expected the page which started the sequence to be reloaded, got %v", reloaded)
+	}
+
+	if cmd := msg.resume(current); cmd != nil {
+		t.Fatalf("expected a form, got %#v", cmd())
+	}
+
+	select {
+	case form := <-currentForms:
+		// the submitted action still targets the extension which started the sequence
+		retry := form.submitMsg(map[string]any{"text": "filled"}).(nextActionsMsg)
+		if extension, _, _ := retry.executor.Extension(""); extension.Entrypoint != originExtension.Entrypoint {
+			t.Errorf("expected the retried action to target %s, got %s", originExtension.Entrypoint, extension.Entrypoint)
+		}
+	case <-originForms:
+		t.Errorf("expected the form to be shown on the current page")
+	}
+}
+
+func TestRunnerExtensionLookup(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	configDir := t.TempDir()
+	writeTestExtension(t, configDir)
+	config.Path = filepath.Join(configDir, "sunbeam.json")
+	if err := os.WriteFile(config.Path, []byte(`{"extensions": {"other": {"origin": "./test.sh", "preferences": {"token": "abc"}}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	extension := newTestExtension(t)
+	runner := NewRunner(extension, sunbeam.Payload{Command: "echo", Preferences: map[string]any{"host": "example.com"}})
+
+	own, preferences, err := runner.executor.Extension("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if own.Entrypoint != extension.Entrypoint || preferences["host"] != "example.com" {
+		t.Errorf("expected the extension of the page, got %s with %v", own.Entrypoint, preferences)
+	}
+
+	other, preferences, err := runner.executor.Extension("other")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if other.Entrypoint != filepath.Join(configDir, "test.sh") || preferences["token"] != "abc" {
+		t.Errorf("expected the extension of the config, got %s with %v", other.Entrypoint, preferences)
+	}
+
+	if _, _, err := runner.executor.Extension("unknown"); err == nil {
+		t.Errorf("expected an error for an unknown alias")
+	}
+}
+
+func TestExecutorPermissions(t *testing.T) {
+	extension := newTestExtension(t)
+	extension.Manifest.Permissions = &sunbeam.Permissions{Read: []string{"data"}}
+
+	executor := testExecutor(extension, nil)
+	executor.Source = func() extensions.Extension {
+		return extension
+	}
+
+	dir := filepath.Dir(extension.Entrypoint)
+	for _, target := range []*sunbeam.OpenAction{
+		{Url: "https://example.com"},
+		{Url: "file:///etc/passwd"},
+		{Path: "/etc/passwd"},
+		{Path: filepath.Join(dir, "..", "secret.txt")},
+	} {
+		msg := executor.Execute(sunbeam.Action{Type: sunbeam.ActionTypeOpen, Open: target})()
+		if !isError(msg) {
+			t.Errorf("expected opening %+v to be refused, got %#v", target, msg)
+		}
+	}
+
+	if err := executor.checkOpen(&sunbeam.OpenAction{Path: filepath.Join(dir, "data", "notes.txt")}); err != nil {
+		t.Errorf("expected a granted path to be allowed: %s", err)
+	}
+
+	msg := executor.Execute(sunbeam.Action{Type: sunbeam.ActionTypeEdit, Edit: &sunbeam.EditAction{Path: "~/.bashrc"}})()
+	if !isError(msg) {
+		t.Errorf("expected editing a file outside of the granted paths to be refused, got %#v", msg)
+	}
+
+	extension.Manifest.Permissions.Network = true
+	if err := executor.checkOpen(&sunbeam.OpenAction{Url: "https://example.com"}); err != nil {
+		t.Errorf("expected urls to be allowed with the network permission: %s", err)
+	}
+}
+
+func TestExecutorConfirm(t *testing.T) {
+	executor := testExecutor(newTestExtension(t), nil)
+	confirm := &sunbeam.Confirm{Message: "Sure?"}
+
+	action := runAction("echo", map[string]any{"text": "confirmed"})
+	action.Confirm = confirm
+
+	msg, ok := executor.Execute(action)().(confirmMsg)
+	if !ok || msg.action.Confirm != confirm {
+		t.Fatalf("expected a confirmation prompt, got %#v", msg)
+	}
+
+	// once confirmed, the action runs without asking again
+	next, ok := msg.run().(nextActionsMsg)
+	if !ok || len(next.actions) != 1 || next.actions[0].Confirm != nil {
+		t.Fatalf("expected the confirmed action, got %#v", next)
+	}
+
+	if msg, ok := next.resume(executor)().(ShowNotificationMsg); !ok || !strings.Contains(msg.Title, "confirmed") {
+		t.Errorf("expected the action to run, got %#v", msg)
+	}
+
+	// the steps of a sequence are confirmed when they are reached
+	sequence := sunbeam.Action{Type: sunbeam.ActionTypeSequence, Sequence: &sunbeam.SequenceAction{Actions: []sunbeam.Action{
+		runAction("echo", map[string]any{"text": "first"}),
+		action,
+		runAction("echo", map[string]any{"text": "last"}),
+	}}}
+
+	then, ok := executor.Execute(sequence)().(thenMsg)
+	if !ok {
+		t.Fatalf("expected the first step to run, got %#v", then)
+	}
+
+	next = then.next().(nextActionsMsg)
+	if msg, ok := next.resume(executor)().(confirmMsg); !ok {
+		t.Errorf("expected the second step to be confirmed, got %#v", msg)
+	} else if next := msg.run().(nextActionsMsg); len(next.actions) != 2 {
+		t.Errorf("expected the rest of the sequence to run once confirmed, got %#v", next)
+	}
+
+	// the confirmation can be handled by a hook
+	var asked []string
+	executor.Confirm = func(action sunbeam.Action, run tea.Cmd) tea.Cmd {
+		asked = append(asked, action.Confirm.Message)
+		return nil
+	}
+
+	if cmd := executor.Execute(action); cmd != nil || len(asked) != 1 {
+		t.Errorf("expected the hook to be called once, got %v", asked)
+	}
+}
@@ -1,9 +1,11 @@
 package tui
 
 import (
+	"fmt"
 	"sort"
 	"strings"
 
+	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -14,6 +16,8 @@ type FilterItem interface {
 	FilterValue() string
 	Render(width int, selected bool) string
 	ID() string
+	// Group returns the section of the item, or an empty string if it has none
+	Group() string
 }
 
 type Filter struct {
@@ -28,6 +32,14 @@ type Filter struct {
 
 	DrawLines bool
 	cursor    int
+
+	// MultiSelect allows the user to select several items
+	MultiSelect bool
+	selected    map[string]bool
+
+	// LoadingMore shows a row at the end of the items while the next page is fetched
+	LoadingMore bool
+	spinner     spinner.Model
 }
 
 func NewFilter(items ...FilterItem) Filter {
@@ -37,6 +49,8 @@ func NewFilter(items ...FilterItem) Filter {
 	return Filter{
 		items:    items,
 		filtered: items,
+		selected: make(map[string]bool),
+		spinner:  spinner.New(),
 	}
 }
 
@@ -59,7 +73,16 @@ func (f Filter) Selection() FilterItem {
 
 func (f *Filter) SetItems(items ...FilterItem) {
 	f.items = items
-	f.filtered = items
+	f.filtered = f.groupBySection(items)
+
+	// drop the selected items that are not part of the list anymore
+	selected := make(map[string]bool)
+	for _, item := range items {
+		if f.selected[item.ID()] {
+			selected[item.ID()] = true
+		}
+	}
+	f.selected = selected
 
 	if f.cursor < 0 {
 		f.cursor = 0
@@ -79,20 +102,36 @@ func (f *Filter) FilterItems(query string) {
 	// If the search field is empty, let's not display the matches
 	// (none), but rather display all possible choices.
 	if query == "" {
-		f.filtered = f.items
+		f.filtered = f.groupBySection(f.items)
 	} else {
-		f.filtered = make([]FilterItem, 0)
+		type match struct {
+			item  FilterItem
+			score int
+		}
+
+		matches := make([]match, 0)
 		for i := 0; i < len(f.items); i++ {
 			filterValue := f.items[i].FilterValue()
 			score := fzf.Score(filterValue, query)
 			if score > 0 {
-				f.filtered = append(f.filtered, f.items[i])
+				matches = append(matches, match{item: f.items[i], score: score})
 			}
 		}
 
-		sort.SliceStable(f.filtered, func(i, j int) bool {
-			return fzf.Score(f.filtered[i].FilterValue(), query) > fzf.Score(f.filtered[j].FilterValue(), query)
+		sort.SliceStable(matches, func(i, j int) bool {
+			// Less is used to break the ties
+			if matches[i].score == matches[j].score && f.Less != nil {
+				return f.Less(matches[i].item, matches[j].item)
+			}
+
+			return matches[i].score > matches[j].score
 		})
+
+		f.filtered = make([]FilterItem, len(matches))
+		for i, match := range matches {
+			f.filtered[i] = match.item
+		}
+		f.filtered = f.groupBySection(f.filtered)
 	}
 
 	if f.cursor >= len(f.filtered) {
@@ -100,12 +139,120 @@ func (f *Filter) FilterItems(query string) {
 	}
 }
 
+// groupBySection keeps the items of a section together.
+// The sections are ordered by their first item, so the best match of a query stays at the top.
+func (f Filter) groupBySection(items []FilterItem) []FilterItem {
+	rank := make(map[string]int)
+	for _, item := range items {
+		if _, ok := rank[item.Group()]; !ok {
+			rank[item.Group()] = len(rank)
+		}
+	}
+
+	if len(rank) < 2 {
+		return items
+	}
+
+	grouped := make([]FilterItem, len(items))
+	copy(grouped, items)
+	sort.SliceStable(grouped, func(i, j int) bool {
+		return rank[grouped[i].Group()] < rank[grouped[j].Group()]
+	})
+
+	return grouped
+}
+
+// lineCost returns the number of lines needed to render the item at the given index,
+// including the separator or section header drawn above it
+func (f Filter) lineCost(index int, first bool) int {
+	section := f.filtered[index].Group()
+	if first {
+		if section != "" {
+			return 2
+		}
+		return 1
+	}
+
+	if section != f.filtered[index-1].Group() {
+		return 2
+	}
+
+	if f.DrawLines {
+		return 2
+	}
+
+	return 1
+}
+
+// visibleItems returns the number of items that fit in the view, starting from the given index
+func (f Filter) visibleItems(start int) int {
+	lines, count := 0, 0
+	for i := start; i < len(f.filtered); i++ {
+		lines += f.lineCost(i, i == start)
+		if lines > f.Height {
+			break
+		}
+		count++
+	}
+
+	return count
+}
+
 func (f *Filter) Select(id string) {
 	for i, item := range f.filtered {
 		if item.ID() == id {
 			f.cursor = i
 		}
 	}
+
+	if f.cursor < f.minIndex {
+		f.minIndex = f.cursor
+	}
+
+	for f.cursor >= f.minIndex+max(f.visibleItems(f.minIndex), 1) {
+		f.minIndex++
+	}
+}
+
+// ToggleSelection adds or removes the item under the cursor from the selected items
+func (f *Filter) ToggleSelection() {
+	item := f.Selection()
+	if item == nil {
+		return
+	}
+
+	if f.selected == nil {
+		f.selected = make(map[string]bool)
+	}
+
+	if f.selected[item.ID()] {
+		delete(f.selected, item.ID())
+	} else {
+		f.selected[item.ID()] = true
+	}
+}
+
+// extendSelection adds the item under the cursor to the selected items
+func (f *Filter) extendSelection() {
+	if item := f.Selection(); item != nil && !f.selected[item.ID()] {
+		f.ToggleSelection()
+	}
+}
+
+func (f *Filter) ClearSelection() {
+	f.selected = make(map[string]bool)
+}
+
+// SelectedItems returns the selected items, in the order of the list
+func (f Filter) SelectedItems() []FilterItem {
+	items := make([]FilterItem, 0, len(f.selected))
+	for _, item := range f.items {
+		if f.selected[item.ID()] {
+			items = append(items, item)
+		}
+	}
+
+	return items
 }
 
 func (m Filter) Init() tea.Cmd { return nil }
@@ -129,21 +276,36 @@ func (m Filter) View() string {
 	}
 
 	index := m.minIndex
-	nbVisibleItems := m.Height
-	if m.DrawLines {
-		nbVisibleItems = nbVisibleItems/2 + nbVisibleItems%2
+	nbVisibleItems := m.visibleItems(m.minIndex)
+
+	// keep a column for the selection marks while items are selected
+	hasSelection := len(m.selected) > 0
+	if hasSelection {
+		itemWidth = max(0, itemWidth-2)
 	}
 
 	for nbVisibleItems > 0 && index < len(m.filtered) {
 		item := m.filtered[index]
+		if section := item.Group(); section != "" && (index == m.minIndex || section != m.filtered[index-1].Group()) {
+			rows = append(rows, lipgloss.NewStyle().Faint(true).Bold(true).Render(fmt.Sprintf("  %s", section)))
+		}
+
 		itemView := item.Render(itemWidth, index == m.cursor)
+		if hasSelection {
+			mark := "  "
+			if m.selected[item.ID()] {
+				mark = lipgloss.NewStyle().Foreground(lipgloss.Color("13")).Render("● ")
+			}
+			itemView = mark + itemView
+		}
 		rows = append(rows, itemView)
 
 		index++
 		nbVisibleItems--
 
-		if m.DrawLines && index < len(m.filtered) && nbVisibleItems > 0 {
-			separator := strings.Repeat("─", itemWidth)
+		// section headers already separate the items
+		if m.DrawLines && index < len(m.filtered) && nbVisibleItems > 0 && m.filtered[index].Group() == item.Group() {
+			separator := strings.Repeat("─", m.Width-2)
 			separator = lipgloss.NewStyle().Faint(true).Render(separator)
 			rows = append(rows, separator)
 		}
@@ -153,6 +315,10 @@ func (m Filter) View() string {
 		return ""
 	}
 
+	if m.LoadingMore && index == len(m.filtered) && len(rows) < m.Height {
+		rows = append(rows, fmt.Sprintf(" %s %s", m.spinner.View(), lipgloss.NewStyle().Faint(true).Render("Loading more…")))
+	}
+
 	filteredView := lipgloss.JoinVertical(lipgloss.Left, rows...)
 	filteredView = lipgloss.NewStyle().Padding(0, 1).Render(filteredView)
 	return lipgloss.Place(m.Width, m.Height, lipgloss.Left, lipgloss.Top, filteredView)
@@ -166,27 +332,42 @@ func (f Filter) Update(msg tea.Msg) (Filter, tea.Cmd) {
 			f.CursorDown()
 		case "up", "ctrl+k", "ctrl+p":
 			f.CursorUp()
+		case "shift+down":
+			if !f.MultiSelect {
+				break
+			}
+
+			f.extendSelection()
+			f.CursorDown()
+			f.extendSelection()
+		case "shift+up":
+			if !f.MultiSelect {
+				break
+			}
+
+			f.extendSelection()
+			f.CursorUp()
+			f.extendSelection()
 		case "ctrl+u":
-			shift := min(f.nbVisibleItems(), f.cursor)
+			shift := min(f.visibleItems(f.minIndex), f.cursor)
 			for i := 0; i < shift; i++ {
 				f.CursorUp()
 			}
 		case "ctrl+d":
-			shift := min(f.nbVisibleItems(), len(f.filtered)-f.cursor-1)
+			shift := min(f.visibleItems(f.minIndex), len(f.filtered)-f.cursor-1)
 			for i := 0; i < shift; i++ {
 				f.CursorDown()
 			}
 		}
 	}
 
-	return f, nil
-}
-
-func (m Filter) itemHeight() int {
-	if m.DrawLines {
-		return 2
+	if f.LoadingMore {
+		var cmd tea.Cmd
+		f.spinner, cmd = f.spinner.Update(msg)
+		return f, cmd
 	}
-	return 1
+
+	return f, nil
 }
 
 func (m *Filter) CursorUp() {
@@ -197,21 +378,20 @@ func (m *Filter) CursorUp() {
 		}
 	} else {
 		m.cursor = len(m.filtered) - 1
-		m.minIndex = max(0, m.cursor-m.nbVisibleItems()+1)
+		m.minIndex = m.cursor
+		for m.minIndex > 0 && m.minIndex-1+max(m.visibleItems(m.minIndex-1), 1) > m.cursor {
+			m.minIndex--
+		}
 	}
 }
 
-func (m Filter) nbVisibleItems() int {
-	return m.Height/m.itemHeight() + m.Height%m.itemHeight()
-}
-
 func (m *Filter) CursorDown() {
 	if m.cursor < len(m.filtered)-1 {
 		m.cursor += 1
-		if m.cursor >= m.minIndex+m.nbVisibleItems() {
+		for m.cursor >= m.minIndex+max(m.visibleItems(m.minIndex), 1) {
 			m.minIndex += 1
 		}
-	} else {
+	} else if !m.LoadingMore {
 		m.cursor = 0
 		m.minIndex = 0
 	}
@@ -0,0 +1,48 @@
+package tui
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+func TestFilterItems(t *testing.T) {
+	filter := NewFilter(
+		ListItem(sunbeam.ListItem{Title: "readme", Section: "Files"}),
+		ListItem(sunbeam.ListItem{Title: "sunbeam", Section: "Repositories"}),
+		ListItem(sunbeam.ListItem{Title: "sunbeam.json", Section: "Files"}),
+		ListItem(sunbeam.ListItem{Title: "docs", Section: "Repositories"}),
+	)
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{
+			name:     "sections in the order of the items",
+			expected: []string{"readme", "sunbeam.json", "sunbeam", "docs"},
+		},
+		{
+			name:     "section of the best match first",
+			query:    "sunbeam",
+			expected: []string{"sunbeam", "sunbeam.json"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter.FilterItems(tt.query)
+
+			var titles []string
+			for _, item := range filter.filtered {
+				titles = append(titles, item.(ListItem).Title)
+			}
+
+			if !reflect.DeepEqual(titles, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, titles)
+			}
+		})
+	}
+}
@@ -1,16 +1,21 @@
 package tui
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/pomdtr/sunbeam/internal/config"
 	"github.com/pomdtr/sunbeam/internal/extensions"
+	"github.com/pomdtr/sunbeam/internal/secrets"
 	"github.com/pomdtr/sunbeam/pkg/sunbeam"
 )
 
@@ -26,6 +31,41 @@ type Form struct {
 	focusIndex   int
 
 	inputs []Input
+
+	OptionsProvider OptionsProvider
+	picker          *optionPicker
+}
+
+// OptionsProvider returns the options of a select input backed by a command
+type OptionsProvider func(input sunbeam.Input) ([]sunbeam.ListItem, error)
+
+type optionsMsg struct {
+	name  string
+	items []sunbeam.ListItem
+	err   error
+}
+
+type optionPicker struct {
+	name   string
+	input  textinput.Model
+	filter Filter
+}
+
+func newOptionPicker(name string) *optionPicker {
+	input := textinput.New()
+	input.Prompt = ""
+	input.PlaceholderStyle = lipgloss.NewStyle().Faint(true)
+	input.Placeholder = "Search Options..."
+
+	filter := NewFilter()
+	filter.DrawLines = true
+	filter.EmptyText = "Loading..."
+
+	return &optionPicker{
+		name:   name,
+		input:  input,
+		filter: filter,
+	}
 }
 
 func ExtractPreferencesFromEnv(alias string, extension extensions.Extension) (map[string]any, error) {
@@ -35,7 +75,7 @@ func ExtractPreferencesFromEnv(alias string, extension extensions.Extension) (ma
 		env = strings.ReplaceAll(env, "-", "_")
 		if value, ok := os.LookupEnv(env); ok {
 			switch input.Type {
-			case sunbeam.InputString:
+			case sunbeam.InputString, sunbeam.InputSecret:
 				preferences[input.Name] = value
 			case sunbeam.InputBoolean:
 				value, err := strconv.ParseBool(value)
@@ -50,6 +90,12 @@ func ExtractPreferencesFromEnv(alias string, extension extensions.Extension) (ma
 					return nil, err
 				}
 
+				preferences[input.Name] = value
+			case sunbeam.InputSelect:
+				if len(input.Options) > 0 && !slices.Contains(input.Options, value) {
+					return nil, fmt.Errorf("invalid value for %s: %s is not one of %s", env, value, strings.Join(input.Options, ", "))
+				}
+
 				preferences[input.Name] = value
 			}
 			continue
@@ -59,6 +105,86 @@ func ExtractPreferencesFromEnv(alias string, extension extensions.Extension) (ma
 	return preferences, nil
 }
 
+// ExtractPreferencesFromSecrets returns the secret preferences of the extension found in the secret store
+func ExtractPreferencesFromSecrets(alias string, extension extensions.Extension) (map[string]any, error) {
+	preferences := make(map[string]any)
+	for _, input := range extension.Manifest.Preferences {
+		if input.Type != sunbeam.InputSecret {
+			continue
+		}
+
+		store, err := secrets.Open()
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := store.Get(secrets.Key(alias, input.Name))
+		if errors.Is(err, secrets.ErrNotFound) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		preferences[input.Name] = value
+	}
+
+	return preferences, nil
+}
+
+// ResolvePreferences merges the preferences of the extension found in the config, the secret store and the environment
+func ResolvePreferences(alias string, extension extensions.Extension, extensionConfig config.ExtensionConfig) (map[string]any, error) {
+	preferences := make(map[string]any)
+	for name, value := range extensionConfig.Preferences {
+		preferences[name] = value
+	}
+
+	secretPreferences, err := ExtractPreferencesFromSecrets(alias, extension)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, value := range secretPreferences {
+		preferences[name] = value
+	}
+
+	envs, err := ExtractPreferencesFromEnv(alias, extension)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, value := range envs {
+		preferences[name] = value
+	}
+
+	return preferences, nil
+}
+
+// StoreSecretPreferences moves the secret preferences from values to the secret store
+func StoreSecretPreferences(alias string, extension extensions.Extension, values map[string]any) error {
+	for _, input := range extension.Manifest.Preferences {
+		if input.Type != sunbeam.InputSecret {
+			continue
+		}
+
+		value, ok := values[input.Name].(string)
+		if !ok {
+			continue
+		}
+		delete(values, input.Name)
+
+		store, err := secrets.Open()
+		if err != nil {
+			return err
+		}
+
+		if err := store.Set(secrets.Key(alias, input.Name), value); err != nil {
+			return fmt.Errorf("failed to store secret %s: %w", input.Name, err)
+		}
+	}
+
+	return nil
+}
+
 func FindMissingPreferences(preferenceInputs []sunbeam.Input, values map[string]any) []sunbeam.Input {
 	preferenceParams := make(map[string]any)
 	for name, value := range values {
@@ -95,10 +221,19 @@ func NewForm(submitMsg func(map[string]any) tea.Msg, params ...sunbeam.Input) *F
 		switch param.Type {
 		case sunbeam.InputString:
 			inputs = append(inputs, NewTextField(param, false))
+		case sunbeam.InputSecret:
+			inputs = append(inputs, NewTextField(param, true))
 		case sunbeam.InputBoolean:
 			inputs = append(inputs, NewCheckbox(param))
 		case sunbeam.InputNumber:
 			inputs = append(inputs, NewNumberField(param))
+		case sunbeam.InputSelect:
+			if param.OptionsCommand != "" {
+				inputs = append(inputs, NewPicker(param))
+				continue
+			}
+
+			inputs = append(inputs, NewSelect(param))
 		}
 	}
 
@@ -143,6 +278,15 @@ func (c *Form) CurrentItem() Input {
 	return c.inputs[c.focusIndex]
 }
 
+// InputNames returns the names of the inputs of the form
+func (c Form) InputNames() []string {
+	var names []string
+	for _, input := range c.inputs {
+		names = append(names, input.Name())
+	}
+	return names
+}
+
 func (f Form) itemsHeight() int {
 	height := 0
 	for _, item := range f.inputs {
@@ -173,6 +317,45 @@ func (c *Form) ScrollViewport() {
 }
 
 func (c Form) Update(msg tea.Msg) (Page, tea.Cmd) {
+	switch msg := msg.(type) {
+	case OpenPickerMsg:
+		if c.OptionsProvider == nil {
+			return &c, nil
+		}
+
+		c.picker = newOptionPicker(msg.Input.Name)
+		c.picker.filter.SetSize(c.width, max(0, c.height-4))
+
+		provider, input := c.OptionsProvider, msg.Input
+		return &c, tea.Batch(c.picker.input.Focus(), func() tea.Msg {
+			items, err := provider(input)
+			return optionsMsg{name: input.Name, items: items, err: err}
+		})
+	case optionsMsg:
+		if c.picker == nil || c.picker.name != msg.name {
+			return &c, nil
+		}
+
+		if msg.err != nil {
+			c.picker.filter.EmptyText = msg.err.Error()
+			return &c, nil
+		}
+
+		items := make([]FilterItem, len(msg.items))
+		for i, item := range msg.items {
+			items[i] = ListItem(item)
+		}
+
+		c.picker.filter.EmptyText = ""
+		c.picker.filter.SetItems(items...)
+		c.picker.filter.FilterItems(c.picker.input.Value())
+		return &c, nil
+	}
+
+	if c.picker != nil {
+		return c.updatePicker(msg)
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
@@ -236,6 +419,52 @@ func (c Form) Update(msg tea.Msg) (Page, tea.Cmd) {
 	return &c, tea.Batch(cmds...)
 }
 
+// IsPicking reports whether the options picker of a select input is open
+func (c *Form) IsPicking() bool {
+	return c.picker != nil
+}
+
+func (c *Form) updatePicker(msg tea.Msg) (Page, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			c.picker = nil
+			return c, nil
+		case "enter":
+			selection := c.picker.filter.Selection()
+			if selection == nil {
+				return c, nil
+			}
+
+			item := selection.(ListItem)
+			for _, input := range c.inputs {
+				if picker, ok := input.(*Picker); ok && picker.Name() == c.picker.name {
+					picker.SetValue(item.ID(), item.Title)
+				}
+			}
+
+			c.picker = nil
+			c.renderInputs()
+			return c, nil
+		}
+	}
+
+	var cmds []tea.Cmd
+	input, cmd := c.picker.input.Update(msg)
+	if input.Value() != c.picker.input.Value() {
+		c.picker.filter.FilterItems(input.Value())
+		c.picker.filter.ResetSelection()
+	}
+	c.picker.input = input
+	cmds = append(cmds, cmd)
+
+	c.picker.filter, cmd = c.picker.filter.Update(msg)
+	cmds = append(cmds, cmd)
+
+	return c, tea.Batch(cmds...)
+}
+
 func (c *Form) renderInputs() {
 	selectedBorder := lipgloss.NewStyle().Border(lipgloss.RoundedBorder(), true).BorderForeground(lipgloss.Color("13"))
 	normalBorder := lipgloss.NewStyle().Border(lipgloss.RoundedBorder(), true)
@@ -285,6 +514,10 @@ func (c *Form) SetSize(width, height int) {
 		input.SetWidth(width / 2)
 	}
 
+	if c.picker != nil {
+		c.picker.filter.SetSize(width, max(0, height-4))
+	}
+
 	c.renderInputs()
 
 	itemsHeight := c.itemsHeight()
@@ -295,6 +528,12 @@ func (c *Form) SetSize(width, height int) {
 }
 
 func (c *Form) View() string {
+	if c.picker != nil {
+		headerRow := fmt.Sprintf("   %s", c.picker.input.View())
+		footerRow := lipgloss.NewStyle().Align(lipgloss.Right).Padding(0, 1).Width(c.width).Render(fmt.Sprintf("%s · %s", renderAction("Select", "enter", false), renderAction("Cancel", "esc", false)))
+		return lipgloss.JoinVertical(lipgloss.Left, headerRow, separator(c.width), c.picker.filter.View(), separator(c.width), footerRow)
+	}
+
 	separator := strings.Repeat("─", c.width)
 	submitRow := lipgloss.NewStyle().Align(lipgloss.Right).Padding(0, 1).Width(c.width).Render(fmt.Sprintf("%s · %s", renderAction("Submit", "alt+enter", false), renderAction("Focus Next", "tab", false)))
 	return lipgloss.JoinVertical(lipgloss.Left, c.viewport.View(), separator, submitRow)
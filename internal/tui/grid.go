@@ -0,0 +1,410 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/truncate"
+	"github.com/pomdtr/sunbeam/internal/images"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+const (
+	// a cell is made of a bordered box of 3 lines, followed by the title
+	gridCellHeight   = 6
+	gridCellMinWidth = 16
+)
+
+type GridItem sunbeam.GridItem
+
+func (i GridItem) ID() string {
+	if i.Id != "" {
+		return i.Id
+	}
+	return i.Title
+}
+
+func (i GridItem) FilterValue() string {
+	return i.Title
+}
+
+func (i GridItem) Group() string {
+	return ""
+}
+
+func (i GridItem) Render(width int, selected bool) string {
+	if width < 4 {
+		return ""
+	}
+
+	var content string
+	switch {
+	case i.Content.Emoji != "":
+		content = i.Content.Emoji
+	case i.Content.Image != "":
+		// images are already sized to fit in the cell
+		image, err := images.Render(i.Content.Image, width-4, 3)
+		if errors.Is(err, images.ErrLoading) {
+			image = lipgloss.NewStyle().Faint(true).Render("…")
+		} else if err != nil {
+			image = lipgloss.NewStyle().Faint(true).Render("image")
+		}
+		content = image
+	default:
+		lines := strings.Split(i.Content.Text, "\n")
+		if len(lines) > 3 {
+			lines = lines[:3]
+		}
+
+		for idx, line := range lines {
+			lines[idx] = truncate.StringWithTail(line, uint(width-4), "…")
+		}
+		content = strings.Join(lines, "\n")
+	}
+
+	boxStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		Width(width-2).
+		Height(3).
+		Align(lipgloss.Center, lipgloss.Center)
+	titleStyle := lipgloss.NewStyle().Width(width).Align(lipgloss.Center)
+
+	if selected {
+		boxStyle = boxStyle.BorderForeground(lipgloss.Color("13"))
+		titleStyle = titleStyle.Foreground(lipgloss.Color("13")).Bold(true)
+	} else {
+		boxStyle = boxStyle.BorderForeground(lipgloss.Color("8"))
+		titleStyle = titleStyle.Faint(true)
+	}
+
+	title := truncate.StringWithTail(strings.Split(i.Title, "\n")[0], uint(width), "…")
+	return lipgloss.JoinVertical(lipgloss.Left, boxStyle.Render(content), titleStyle.Render(title))
+}
+
+type Grid struct {
+	width, height int
+	columns       int
+	minRow        int
+
+	query string
+	input textinput.Model
+
+	spinner   spinner.Model
+	filter    Filter
+	statusBar StatusBar
+
+	isLoading bool
+	focus     ListFocus
+	Actions   []sunbeam.Action
+}
+
+func NewGrid(items ...sunbeam.GridItem) *Grid {
+	input := textinput.New()
+	input.Prompt = ""
+	input.PlaceholderStyle = lipgloss.NewStyle().Faint(true)
+	input.Placeholder = "Search Items..."
+
+	grid := &Grid{
+		spinner:   spinner.New(),
+		input:     input,
+		filter:    NewFilter(),
+		statusBar: NewStatusBar(),
+		focus:     ListFocusItems,
+	}
+
+	grid.SetItems(items...)
+	return grid
+}
+
+// SetColumns sets the number of columns, if 0 it is computed from the width of the page
+func (c *Grid) SetColumns(columns int) {
+	c.columns = columns
+	c.scrollToCursor()
+}
+
+func (c *Grid) SetItems(items ...sunbeam.GridItem) {
+	filterItems := make([]FilterItem, len(items))
+	for i, item := range items {
+		filterItems[i] = GridItem(item)
+	}
+
+	// the images are loaded in the background, their cells show a placeholder until then
+	for _, item := range items {
+		if item.Content.Image != "" {
+			images.Request(item.Content.Image)
+		}
+	}
+
+	c.filter.SetItems(filterItems...)
+	c.filter.FilterItems(c.query)
+	c.scrollToCursor()
+	c.statusBar.SetActions(c.actions()...)
+}
+
+func (c *Grid) SetActions(actions ...sunbeam.Action) {
+	c.Actions = actions
+	if c.filter.Selection() == nil {
+		c.statusBar.SetActions(actions...)
+	}
+}
+
+func (c *Grid) SetEmptyText(text string) {
+	c.filter.EmptyText = text
+}
+
+func (c *Grid) SetIsLoading(isLoading bool) tea.Cmd {
+	c.isLoading = isLoading
+	if isLoading {
+		return c.spinner.Tick
+	}
+	return nil
+}
+
+func (c *Grid) SetStatus(status string) {
+	c.statusBar.SetStatus(status)
+}
+
+func (c Grid) Selection() (sunbeam.GridItem, bool) {
+	selection := c.filter.Selection()
+	if selection == nil {
+		return sunbeam.GridItem{}, false
+	}
+
+	return sunbeam.GridItem(selection.(GridItem)), true
+}
+
+func (c Grid) actions() []sunbeam.Action {
+	if selection := c.filter.Selection(); selection != nil {
+		return selection.(GridItem).Actions
+	}
+
+	return c.Actions
+}
+
+func (c *Grid) Init() tea.Cmd {
+	return c.input.Focus()
+}
+
+func (c *Grid) Focus() tea.Cmd {
+	c.statusBar.Reset()
+	c.focus = ListFocusItems
+	c.input.Placeholder = "Search Items..."
+	c.input.SetValue(c.query)
+
+	return c.input.Focus()
+}
+
+func (c *Grid) Blur() tea.Cmd {
+	return nil
+}
+
+func (c *Grid) SetSize(width, height int) {
+	c.width, c.height = width, height
+	c.statusBar.Width = width
+	c.scrollToCursor()
+}
+
+func (c Grid) nbColumns() int {
+	if c.columns > 0 {
+		return c.columns
+	}
+
+	return max(1, (c.width-2)/gridCellMinWidth)
+}
+
+func (c Grid) nbVisibleRows() int {
+	return max(1, (c.height-4)/gridCellHeight)
+}
+
+// scrollToCursor makes sure the row of the selected item is visible
+func (c *Grid) scrollToCursor() {
+	row := max(c.filter.cursor, 0) / c.nbColumns()
+	if row < c.minRow {
+		c.minRow = row
+	}
+
+	if row >= c.minRow+c.nbVisibleRows() {
+		c.minRow = row - c.nbVisibleRows() + 1
+	}
+}
+
+// moveCursor moves the cursor by the given offset, staying on the last item when moving past the end
+func (c *Grid) moveCursor(offset int) {
+	if len(c.filter.filtered) == 0 {
+		return
+	}
+
+	cursor := c.filter.cursor + offset
+	if cursor < 0 {
+		if offset != -1 {
+			return
+		}
+		cursor = len(c.filter.filtered) - 1
+	} else if cursor >= len(c.filter.filtered) {
+		switch {
+		case offset == 1:
+			cursor = 0
+		case c.filter.cursor/c.nbColumns() < (len(c.filter.filtered)-1)/c.nbColumns():
+			// the next row is not complete
+			cursor = len(c.filter.filtered) - 1
+		default:
+			return
+		}
+	}
+
+	c.filter.cursor = cursor
+	c.scrollToCursor()
+	c.statusBar.SetActions(c.actions()...)
+}
+
+func (c *Grid) setQuery(query string) {
+	c.input.SetValue(query)
+	if c.focus != ListFocusItems {
+		c.statusBar.FilterActions(query)
+		return
+	}
+
+	c.query = query
+	c.filter.FilterItems(query)
+	c.filter.ResetSelection()
+	c.minRow = 0
+	c.statusBar.SetActions(c.actions()...)
+}
+
+func (c *Grid) Update(msg tea.Msg) (Page, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		// the key presses answer the confirmation prompt
+		if c.statusBar.Confirming() {
+			statusBar, cmd := c.statusBar.Update(msg)
+			c.statusBar = statusBar
+			return c, cmd
+		}
+
+		switch msg.String() {
+		case "esc":
+			if c.statusBar.expanded {
+				c.focus = ListFocusItems
+				c.input.SetValue(c.query)
+				c.input.Placeholder = "Search Items..."
+
+				c.statusBar.Reset()
+				return c, nil
+			}
+
+			if c.input.Value() != "" {
+				c.setQuery("")
+				return c, nil
+			}
+
+			return c, PopPageCmd
+		case "tab":
+			if c.statusBar.expanded || len(c.statusBar.actions) < 2 {
+				break
+			}
+
+			c.input.SetValue("")
+			c.input.Placeholder = "Search Actions..."
+			c.statusBar.expanded = true
+			c.focus = ListFocusActions
+			return c, nil
+		case "right", "left":
+			if c.statusBar.expanded {
+				break
+			}
+
+			if msg.String() == "right" {
+				c.moveCursor(1)
+			} else {
+				c.moveCursor(-1)
+			}
+			return c, nil
+		case "up", "ctrl+p", "ctrl+k":
+			c.moveCursor(-c.nbColumns())
+			return c, nil
+		case "down", "ctrl+n", "ctrl+j":
+			c.moveCursor(c.nbColumns())
+			return c, nil
+		}
+	}
+
+	statusBar, cmd := c.statusBar.Update(msg)
+	c.statusBar = statusBar
+	if cmd != nil {
+		return c, cmd
+	}
+
+	var cmds []tea.Cmd
+	input, cmd := c.input.Update(msg)
+	if input.Value() != c.input.Value() {
+		c.setQuery(input.Value())
+	}
+	c.input = input
+	cmds = append(cmds, cmd)
+
+	if c.isLoading {
+		c.spinner, cmd = c.spinner.Update(msg)
+		cmds = append(cmds, cmd)
+	}
+
+	return c, tea.Batch(cmds...)
+}
+
+func (c Grid) View() string {
+	var headerRow string
+	if c.isLoading {
+		headerRow = fmt.Sprintf(" %s %s", c.spinner.View(), c.input.View())
+	} else {
+		headerRow = fmt.Sprintf("   %s", c.input.View())
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, headerRow, separator(c.width), c.gridView(), c.statusBar.View())
+}
+
+func (c Grid) gridView() string {
+	width, height := c.width, max(0, c.height-4)
+
+	if len(c.filter.filtered) == 0 {
+		var emptyText string
+		if c.filter.EmptyText != "" {
+			emptyText = c.filter.EmptyText
+		} else if len(c.filter.items) > 0 && c.query != "" {
+			emptyText = "No matches"
+		} else {
+			emptyText = "No Items"
+		}
+
+		emptyText = lipgloss.NewStyle().Faint(true).Render(emptyText)
+		return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, emptyText)
+	}
+
+	columns := c.nbColumns()
+	cellWidth := (width - 2) / columns
+
+	var rows []string
+	for row := c.minRow; row < c.minRow+c.nbVisibleRows(); row++ {
+		var cells []string
+		for col := 0; col < columns; col++ {
+			index := row*columns + col
+			if index >= len(c.filter.filtered) {
+				break
+			}
+
+			cells = append(cells, c.filter.filtered[index].Render(cellWidth, index == c.filter.cursor))
+		}
+
+		if len(cells) == 0 {
+			break
+		}
+
+		rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, cells...))
+	}
+
+	view := lipgloss.NewStyle().Padding(0, 1).Render(lipgloss.JoinVertical(lipgloss.Left, rows...))
+	return lipgloss.Place(width, height, lipgloss.Left, lipgloss.Top, view)
+}
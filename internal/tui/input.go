@@ -319,3 +319,191 @@ func (n NumberField) Update(msg tea.Msg) (Input, tea.Cmd) {
 
 	return n, nil
 }
+
+type Select struct {
+	name    string
+	title   string
+	options []string
+	width   int
+
+	focused bool
+	cursor  int
+}
+
+func NewSelect(param sunbeam.Input) *Select {
+	s := Select{
+		name:    param.Name,
+		title:   param.Title,
+		options: param.Options,
+	}
+
+	if defaultValue, ok := param.Default.(string); ok {
+		for i, option := range param.Options {
+			if option == defaultValue {
+				s.cursor = i
+			}
+		}
+	}
+
+	return &s
+}
+
+func (s *Select) Name() string {
+	return s.name
+}
+
+func (s *Select) Title() string {
+	return s.title
+}
+
+func (s *Select) Height() int {
+	return 1
+}
+
+func (s *Select) Focus() tea.Cmd {
+	s.focused = true
+	return nil
+}
+
+func (s *Select) Blur() {
+	s.focused = false
+}
+
+func (s *Select) SetWidth(width int) {
+	s.width = width
+}
+
+func (s Select) Update(msg tea.Msg) (Input, tea.Cmd) {
+	if !s.focused || len(s.options) == 0 {
+		return &s, nil
+	}
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "right", "down", " ", "enter":
+			s.cursor = (s.cursor + 1) % len(s.options)
+		case "left", "up":
+			s.cursor = (s.cursor - 1 + len(s.options)) % len(s.options)
+		}
+	}
+
+	return &s, nil
+}
+
+func (s Select) View() string {
+	if len(s.options) == 0 {
+		return strings.Repeat(" ", s.width)
+	}
+
+	value := s.options[s.cursor]
+	hint := fmt.Sprintf(" %d/%d", s.cursor+1, len(s.options))
+	if s.focused {
+		value = fmt.Sprintf("‹ %s ›", value)
+	} else {
+		value = fmt.Sprintf("  %s  ", value)
+	}
+
+	padding := max(0, s.width-lipgloss.Width(value)-lipgloss.Width(hint))
+	return fmt.Sprintf("%s%s%s", value, strings.Repeat(" ", padding), lipgloss.NewStyle().Faint(true).Render(hint))
+}
+
+func (s Select) Value() any {
+	if len(s.options) == 0 {
+		return ""
+	}
+
+	return s.options[s.cursor]
+}
+
+// Picker is a select input whose options are provided by an extension command
+type Picker struct {
+	input sunbeam.Input
+	width int
+
+	focused bool
+	value   string
+	label   string
+}
+
+type OpenPickerMsg struct {
+	Input sunbeam.Input
+}
+
+func NewPicker(param sunbeam.Input) *Picker {
+	picker := Picker{
+		input: param,
+	}
+
+	if defaultValue, ok := param.Default.(string); ok {
+		picker.value = defaultValue
+		picker.label = defaultValue
+	}
+
+	return &picker
+}
+
+func (p *Picker) Name() string {
+	return p.input.Name
+}
+
+func (p *Picker) Title() string {
+	return p.input.Title
+}
+
+func (p *Picker) Height() int {
+	return 1
+}
+
+func (p *Picker) Focus() tea.Cmd {
+	p.focused = true
+	return nil
+}
+
+func (p *Picker) Blur() {
+	p.focused = false
+}
+
+func (p *Picker) SetWidth(width int) {
+	p.width = width
+}
+
+func (p *Picker) SetValue(value string, label string) {
+	p.value = value
+	p.label = label
+}
+
+func (p Picker) Update(msg tea.Msg) (Input, tea.Cmd) {
+	if !p.focused {
+		return &p, nil
+	}
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "enter", " ":
+			input := p.input
+			return &p, func() tea.Msg {
+				return OpenPickerMsg{Input: input}
+			}
+		}
+	}
+
+	return &p, nil
+}
+
+func (p Picker) View() string {
+	var view string
+	if p.label != "" {
+		view = p.label
+	} else {
+		view = lipgloss.NewStyle().Faint(true).Render("Press enter to choose...")
+	}
+
+	padding := max(0, p.width-lipgloss.Width(view))
+	return fmt.Sprintf("%s%s", view, strings.Repeat(" ", padding))
+}
+
+func (p Picker) Value() any {
+	return p.value
+}
@@ -32,13 +32,19 @@ type List struct {
 
 	showDetail           bool
 	isLoading            bool
+	hasMore              bool
 	autoRefreshSeconds   int
 	autoRefreshTriggered bool
+	// hasDates is set when an item has a date accessory, which is rendered relatively to now
+	hasDates     bool
+	clockStarted bool
 
 	focus         ListFocus
 	Actions       []sunbeam.Action
 	OnQueryChange func(string) tea.Cmd
 	OnSelect      func(string) tea.Cmd
+	// OnLoadMore is called when the user reaches the end of the list, to fetch the next page of items
+	OnLoadMore func() tea.Cmd
 }
 
 type ListFocus string
@@ -48,13 +54,16 @@ type tickMsg struct {
 	time time.Time
 }
 
+// clockMsg is sent every minute while the list displays relative dates, so that they are rendered again
+type clockMsg struct {
+	id string
+}
+
 var (
 	ListFocusItems   ListFocus = "items"
 	ListFocusActions ListFocus = "actions"
 )
 
-type QueryChangeMsg string
-
 func NewList(items ...sunbeam.ListItem) *List {
 	filter := NewFilter()
 	filter.DrawLines = true
@@ -85,12 +94,49 @@ func NewList(items ...sunbeam.ListItem) *List {
 
 func (l *List) ResetSelection() {
 	l.filter.ResetSelection()
+	l.statusBar.SetActions(l.actions()...)
+}
+
+// SetMultiSelect allows the user to select several items, to run batch actions on them
+func (l *List) SetMultiSelect(multiSelect bool) {
+	l.filter.MultiSelect = multiSelect
+	if !multiSelect {
+		l.filter.ClearSelection()
+		l.updateSelection()
+	}
+}
+
+// SelectedItems returns the items selected by the user, in the order of the list
+func (l List) SelectedItems() []sunbeam.ListItem {
+	var items []sunbeam.ListItem
+	for _, item := range l.filter.SelectedItems() {
+		items = append(items, sunbeam.ListItem(item.(ListItem)))
+	}
+
+	return items
+}
+
+// actions returns the actions available for the selected items, or for the item under the cursor
+func (l List) actions() []sunbeam.Action {
+	if items := l.filter.SelectedItems(); len(items) > 0 {
+		return batchActions(items)
+	}
 
 	if selection := l.filter.Selection(); selection != nil {
-		l.statusBar.SetActions(selection.(ListItem).Actions...)
-	} else {
-		l.statusBar.SetActions(l.Actions...)
+		item := selection.(ListItem)
+		if l.showDetail && len(item.Detail.Metadata) > 0 {
+			return append(item.Actions[:len(item.Actions):len(item.Actions)], metadataActions(item.Detail.Metadata)...)
+		}
+
+		return item.Actions
 	}
+
+	return l.Actions
+}
+
+func (l *List) updateSelection() {
+	l.statusBar.SetSelectionCount(len(l.filter.selected))
+	l.statusBar.SetActions(l.actions()...)
 }
 
 func (c *List) updateViewport(detail sunbeam.ListItemDetail) {
@@ -111,7 +157,7 @@ func (c *List) updateViewport(detail sunbeam.ListItemDetail) {
 			return
 		}
 
-		content, err = render.Render(detail.Markdown)
+		content, err = renderMarkdown(render, detail.Markdown, c.viewport.Width, max(c.viewport.Height, 1))
 		if err != nil {
 			c.viewport.SetContent(err.Error())
 			return
@@ -124,6 +170,14 @@ func (c *List) updateViewport(detail sunbeam.ListItemDetail) {
 		content = lipgloss.NewStyle().Padding(0, 2).Render(content)
 	}
 
+	if metadata := renderMetadata(detail.Metadata, c.viewport.Width-2); metadata != "" {
+		if content != "" {
+			content = lipgloss.JoinVertical(lipgloss.Left, content, "", metadata)
+		} else {
+			content = metadata
+		}
+	}
+
 	c.viewport.GotoTop()
 	c.viewport.SetContent(content)
 }
@@ -162,15 +216,7 @@ func (c *List) SetQuery(query string) tea.Cmd {
 	if c.focus == ListFocusItems {
 		c.query = query
 		if c.OnQueryChange != nil {
-			return tea.Tick(500*time.Millisecond, func(t time.Time) tea.Msg {
-				emptyText := ""
-				c.filter.EmptyText = emptyText
-				if query == c.input.Value() {
-					return QueryChangeMsg(query)
-				}
-
-				return nil
-			})
+			return c.OnQueryChange(query)
 		}
 
 		c.FilterItems(query)
@@ -184,16 +230,9 @@ func (c *List) SetQuery(query string) tea.Cmd {
 
 func (c *List) FilterItems(query string) {
 	c.filter.FilterItems(query)
-	selection := c.filter.Selection()
-	if selection == nil {
-		c.statusBar.SetActions(c.Actions...)
-	} else {
-		listItem := selection.(ListItem)
-		c.statusBar.SetActions(listItem.Actions...)
-
-		if c.showDetail {
-			c.updateViewport(listItem.Detail)
-		}
+	c.statusBar.SetActions(c.actions()...)
+	if selection := c.filter.Selection(); selection != nil && c.showDetail {
+		c.updateViewport(selection.(ListItem).Detail)
 	}
 }
 
@@ -206,6 +245,7 @@ func (c *List) SetShowDetail(showDetail bool) {
 	if showDetail && c.filter.Selection() != nil {
 		c.updateViewport(c.filter.Selection().(ListItem).Detail)
 	}
+	c.statusBar.SetActions(c.actions()...)
 	c.SetSize(c.width, c.height)
 
 }
@@ -225,6 +265,9 @@ func (c *List) SetSize(width, height int) {
 		}
 
 		c.viewport.Height = availableHeight
+		if selection := c.filter.Selection(); selection != nil {
+			c.updateViewport(selection.(ListItem).Detail)
+		}
 	} else {
 		c.filter.SetSize(width, availableHeight)
 	}
@@ -240,6 +283,24 @@ func (c List) Selection() (sunbeam.ListItem, bool) {
 	return sunbeam.ListItem(item), true
 }
 
+// SetLess sets the order of the items matching the query equally well
+func (c *List) SetLess(less func(i, j sunbeam.ListItem) bool) {
+	c.filter.Less = func(i, j FilterItem) bool {
+		return less(sunbeam.ListItem(i.(ListItem)), sunbeam.ListItem(j.(ListItem)))
+	}
+}
+
+// Select moves the cursor to the item with the given id
+func (c *List) Select(id string) {
+	c.filter.Select(id)
+	if selection := c.filter.Selection(); selection != nil {
+		c.statusBar.SetActions(c.actions()...)
+		if c.showDetail {
+			c.updateViewport(selection.(ListItem).Detail)
+		}
+	}
+}
+
 func (c *List) SetItems(items ...sunbeam.ListItem) {
 	filterItems := make([]FilterItem, len(items))
 	for i, item := range items {
@@ -247,12 +308,90 @@ func (c *List) SetItems(items ...sunbeam.ListItem) {
 	}
 
 	c.filter.SetItems(filterItems...)
+	c.statusBar.SetSelectionCount(len(c.filter.selected))
+	c.hasDates = hasDates(items)
 
 	if c.OnQueryChange == nil {
 		c.FilterItems(c.Query())
 	}
 }
 
+// SetHasMore indicates if more items can be loaded when the user reaches the end of the list
+func (c *List) SetHasMore(hasMore bool) {
+	c.hasMore = hasMore
+	c.filter.LoadingMore = false
+}
+
+// loadMore requests the next page of items if the cursor is on the last item
+func (c *List) loadMore() tea.Cmd {
+	if !c.hasMore || c.filter.LoadingMore || c.OnLoadMore == nil {
+		return nil
+	}
+
+	if c.filter.cursor < len(c.filter.filtered)-1 {
+		return nil
+	}
+
+	c.filter.LoadingMore = true
+	return tea.Batch(c.OnLoadMore(), c.filter.spinner.Tick)
+}
+
+func (c List) clockTick() tea.Cmd {
+	return tea.Every(time.Minute, func(time.Time) tea.Msg {
+		return clockMsg{id: c.id}
+	})
+}
+
+func hasDates(items []sunbeam.ListItem) bool {
+	for _, item := range items {
+		for _, accessory := range item.Accessories {
+			if accessory.Date != nil {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (c List) hasItem(id string) bool {
+	for _, item := range c.filter.items {
+		if item.ID() == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AppendItems adds items to the list, keeping the current selection
+func (c *List) AppendItems(items ...sunbeam.ListItem) {
+	selection := c.filter.Selection()
+
+	filterItems := c.filter.items
+	for _, item := range items {
+		filterItems = append(filterItems, ListItem(item))
+	}
+	c.hasDates = c.hasDates || hasDates(items)
+
+	c.filter.SetItems(filterItems...)
+	if c.OnQueryChange == nil {
+		c.filter.FilterItems(c.Query())
+	}
+
+	if selection != nil {
+		c.filter.Select(selection.ID())
+		return
+	}
+
+	if selection := c.filter.Selection(); selection != nil {
+		c.statusBar.SetActions(c.actions()...)
+		if c.showDetail {
+			c.updateViewport(selection.(ListItem).Detail)
+		}
+	}
+}
+
 func (c *List) SetIsLoading(isLoading bool) tea.Cmd {
 	c.isLoading = isLoading
 	if isLoading {
@@ -261,13 +400,32 @@ func (c *List) SetIsLoading(isLoading bool) tea.Cmd {
 	return nil
 }
 
+func (c *List) SetStatus(status string) {
+	c.statusBar.SetStatus(status)
+}
+
 func (c List) Query() string {
 	return c.query
 }
 
 func (c *List) Update(msg tea.Msg) (Page, tea.Cmd) {
 	switch msg := msg.(type) {
+	case imageLoadedMsg:
+		if selection := c.filter.Selection(); selection != nil && c.showDetail {
+			c.updateViewport(selection.(ListItem).Detail)
+		}
+		return c, nil
+	case clockMsg:
+		if msg.id != c.id {
+			return c, nil
+		}
+
+		if !c.hasDates {
+			c.clockStarted = false
+			return c, nil
+		}
 
+		return c, c.clockTick()
 	case tickMsg:
 		if msg.id == c.id {
 			nextTick := tea.Tick(time.Duration(c.autoRefreshSeconds)*time.Second, func(t time.Time) tea.Msg {
@@ -285,6 +443,13 @@ func (c *List) Update(msg tea.Msg) (Page, tea.Cmd) {
 		}
 		return c, nil
 	case tea.KeyMsg:
+		// the key presses answer the confirmation prompt
+		if c.statusBar.Confirming() {
+			statusBar, cmd := c.statusBar.Update(msg)
+			c.statusBar = statusBar
+			return c, cmd
+		}
+
 		switch msg.String() {
 		case "esc":
 			if c.statusBar.expanded {
@@ -300,7 +465,22 @@ func (c *List) Update(msg tea.Msg) (Page, tea.Cmd) {
 				return c, c.SetQuery("")
 			}
 
+			if len(c.filter.selected) > 0 {
+				c.filter.ClearSelection()
+				c.updateSelection()
+				return c, nil
+			}
+
 			return c, PopPageCmd
+		case " ":
+			// space is only used for selection when the user is not typing a query
+			if !c.filter.MultiSelect || c.focus != ListFocusItems || c.input.Value() != "" {
+				break
+			}
+
+			c.filter.ToggleSelection()
+			c.updateSelection()
+			return c, nil
 		case "ctrl+j":
 			if !c.showDetail {
 				break
@@ -323,11 +503,7 @@ func (c *List) Update(msg tea.Msg) (Page, tea.Cmd) {
 				break
 			}
 
-			selection, ok := c.Selection()
-			if ok && len(selection.Actions) < 2 {
-				break
-			}
-			if !ok && len(c.Actions) < 2 {
+			if len(c.statusBar.actions) < 2 {
 				break
 			}
 
@@ -347,17 +523,6 @@ func (c *List) Update(msg tea.Msg) (Page, tea.Cmd) {
 			c.input = input
 			return c, cmd
 		}
-	case QueryChangeMsg:
-		if c.OnQueryChange == nil {
-			return c, nil
-		}
-
-		if string(msg) != c.input.Value() {
-			return c, nil
-		}
-
-		c.filter.EmptyText = "Loading..."
-		return c, c.OnQueryChange(string(msg))
 	}
 
 	var cmd tea.Cmd
@@ -366,6 +531,10 @@ func (c *List) Update(msg tea.Msg) (Page, tea.Cmd) {
 	statusBar, cmd := c.statusBar.Update(msg)
 	c.statusBar = statusBar
 	if cmd != nil {
+		if items := c.filter.SelectedItems(); len(items) > 0 {
+			return c, batchCmd(cmd, items)
+		}
+
 		return c, cmd
 	}
 
@@ -377,24 +546,31 @@ func (c *List) Update(msg tea.Msg) (Page, tea.Cmd) {
 	c.input = input
 	cmds = append(cmds, cmd)
 
-	filter, cmd := c.filter.Update(msg)
 	oldSelection := c.filter.Selection()
+	nbSelected := len(c.filter.selected)
+	filter, cmd := c.filter.Update(msg)
+	c.filter = filter
 	newSelection := filter.Selection()
 	if newSelection == nil {
 		c.statusBar.SetActionsNoSelection(c.Actions...)
 		if c.showDetail {
 			c.updateViewport(sunbeam.ListItemDetail{})
 		}
-	} else if oldSelection == nil || oldSelection.ID() != newSelection.ID() {
-		listItem := newSelection.(ListItem)
+	} else {
+		cursorMoved := oldSelection == nil || oldSelection.ID() != newSelection.ID()
+		if cursorMoved && c.showDetail {
+			c.updateViewport(newSelection.(ListItem).Detail)
+		}
 
-		if c.showDetail {
-			c.updateViewport(listItem.Detail)
+		if cursorMoved {
+			cmds = append(cmds, c.loadMore())
 		}
 
-		c.statusBar.SetActions(newSelection.(ListItem).Actions...)
+		// the actions of the selected items do not depend on the cursor
+		if len(c.filter.selected) != nbSelected || (cursorMoved && nbSelected == 0) {
+			c.updateSelection()
+		}
 	}
-	c.filter = filter
 	cmds = append(cmds, cmd)
 
 	if c.autoRefreshSeconds > 0 && !c.autoRefreshTriggered {
@@ -408,6 +584,11 @@ func (c *List) Update(msg tea.Msg) (Page, tea.Cmd) {
 		cmds = append(cmds, cmd)
 	}
 
+	if c.hasDates && !c.clockStarted {
+		c.clockStarted = true
+		cmds = append(cmds, c.clockTick())
+	}
+
 	if c.isLoading {
 		c.spinner, cmd = c.spinner.Update(msg)
 		cmds = append(cmds, cmd)
@@ -438,5 +619,10 @@ func (c List) View() string {
 		mainView = c.filter.View()
 	}
 
-	return lipgloss.JoinVertical(lipgloss.Left, headerRow, separator(c.width), mainView, c.statusBar.View())
+	statusBar := c.statusBar
+	if selection := c.filter.Selection(); selection != nil {
+		statusBar.SetHint(selection.(ListItem).Tooltip())
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, headerRow, separator(c.width), mainView, statusBar.View())
 }
@@ -3,8 +3,10 @@ package tui
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/pomdtr/sunbeam/pkg/sunbeam"
 )
 
@@ -18,12 +20,83 @@ func (i ListItem) ID() string {
 	return i.Title
 }
 
+func (i ListItem) Group() string {
+	return i.Section
+}
+
 func (i ListItem) FilterValue() string {
-	keywords := []string{i.Title, i.Subtitle}
+	keywords := []string{i.Title, i.Subtitle, i.Section}
+	for _, accessory := range i.Accessories {
+		keywords = append(keywords, accessory.Text)
+	}
 	return strings.Trim(strings.Join(keywords, " "), " ")
 }
 
-func RenderItem(title string, subtitle string, accessories []string, width int, selected bool) string {
+// Tooltip returns the tooltips of the accessories, displayed when the item is selected
+func (i ListItem) Tooltip() string {
+	var tooltips []string
+	for _, accessory := range i.Accessories {
+		if accessory.Tooltip != "" {
+			tooltips = append(tooltips, accessory.Tooltip)
+		}
+	}
+
+	return strings.Join(tooltips, " · ")
+}
+
+// relativeTime formats the date relatively to now, ex: "3h ago" or "in 2d"
+func relativeTime(date time.Time, now time.Time) string {
+	delta := now.Sub(date)
+	suffix := " ago"
+	if delta < 0 {
+		delta = -delta
+		suffix = ""
+	}
+
+	var text string
+	switch {
+	case delta < time.Minute:
+		return "now"
+	case delta < time.Hour:
+		text = fmt.Sprintf("%dm", int(delta.Minutes()))
+	case delta < 24*time.Hour:
+		text = fmt.Sprintf("%dh", int(delta.Hours()))
+	case delta < 30*24*time.Hour:
+		text = fmt.Sprintf("%dd", int(delta.Hours()/24))
+	case date.Year() == now.Year():
+		return date.Local().Format("Jan 2")
+	default:
+		return date.Local().Format("Jan 2, 2006")
+	}
+
+	if suffix == "" {
+		return "in " + text
+	}
+	return text + suffix
+}
+
+func renderAccessory(accessory sunbeam.Accessory, style lipgloss.Style) string {
+	var parts []string
+	if accessory.Icon != "" {
+		parts = append(parts, accessory.Icon)
+	}
+
+	if accessory.Text != "" {
+		parts = append(parts, strings.Split(accessory.Text, "\n")[0])
+	}
+
+	if accessory.Date != nil {
+		parts = append(parts, relativeTime(*accessory.Date, time.Now()))
+	}
+
+	if accessory.Color != "" {
+		style = style.UnsetFaint().Foreground(tagColor(accessory.Color))
+	}
+
+	return style.Render(strings.Join(parts, " "))
+}
+
+func RenderItem(title string, subtitle string, accessories []sunbeam.Accessory, width int, selected bool) string {
 	if width == 0 {
 		return ""
 	}
@@ -44,7 +117,13 @@ func RenderItem(title string, subtitle string, accessories []string, width int,
 
 	subtitle = strings.Split(subtitle, "\n")[0]
 	subtitle = " " + subtitle
-	accessory := "  " + strings.Join(accessories, " · ")
+
+	// accessories are rendered first, as they can have their own colors
+	rendered := make([]string, len(accessories))
+	for i, accessory := range accessories {
+		rendered[i] = renderAccessory(accessory, accessoryStyle)
+	}
+	accessory := "  " + strings.Join(rendered, accessoryStyle.Render(" · "))
 
 	var blanks string
 
@@ -52,10 +131,10 @@ func RenderItem(title string, subtitle string, accessories []string, width int,
 	for lipgloss.Width(title+subtitle+accessory) > width {
 		if words := strings.Split(subtitle, " "); len(words) > 1 {
 			subtitle = strings.Join(words[:len(words)-1], " ")
-		} else if len(accessory) > 0 {
-			accessory = accessory[:len(accessory)-1]
+		} else if accessoryWidth := lipgloss.Width(accessory); accessoryWidth > 0 {
+			accessory = ansi.Truncate(accessory, accessoryWidth-1, "")
 		} else {
-			title = title[:len(title)-1]
+			title = ansi.Truncate(title, lipgloss.Width(title)-1, "")
 		}
 	}
 
@@ -64,7 +143,6 @@ func RenderItem(title string, subtitle string, accessories []string, width int,
 
 	title = titleStyle.Render(title)
 	subtitle = subtitleStyle.Render(subtitle)
-	accessory = accessoryStyle.Render(accessory)
 
 	return lipgloss.JoinHorizontal(lipgloss.Top, title, subtitle, blanks, accessory)
 
@@ -0,0 +1,69 @@
+package tui
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/pomdtr/sunbeam/internal/images"
+)
+
+var imageRegexp = regexp.MustCompile(`^!\[[^\]]*\]\(\s*<?([^\s)>]+)>?(?:\s+"[^"]*")?\s*\)$`)
+
+// renderMarkdown renders the markdown with glamour, which does not support images.
+// Images standing on their own line are extracted and rendered in the terminal instead.
+func renderMarkdown(renderer *glamour.TermRenderer, markdown string, width int, maxRows int) (string, error) {
+	var blocks []string
+	var chunk []string
+	var fenced bool
+
+	flush := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+
+		rendered, err := renderer.Render(strings.Join(chunk, "\n"))
+		if err != nil {
+			return err
+		}
+
+		blocks = append(blocks, strings.TrimRight(rendered, "\n"))
+		chunk = nil
+		return nil
+	}
+
+	for _, line := range strings.Split(markdown, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fenced = !fenced
+		}
+
+		matches := imageRegexp.FindStringSubmatch(trimmed)
+		if fenced || matches == nil {
+			chunk = append(chunk, line)
+			continue
+		}
+
+		image, err := images.Render(matches[1], width-4, maxRows)
+		if errors.Is(err, images.ErrLoading) {
+			image = lipgloss.NewStyle().Faint(true).Render("Loading image…")
+		} else if err != nil {
+			// let glamour display the image as a link
+			chunk = append(chunk, line)
+			continue
+		}
+
+		if err := flush(); err != nil {
+			return "", err
+		}
+		blocks = append(blocks, "", lipgloss.NewStyle().PaddingLeft(2).Render(image), "")
+	}
+
+	if err := flush(); err != nil {
+		return "", err
+	}
+
+	return strings.Join(blocks, "\n") + "\n", nil
+}
@@ -0,0 +1,137 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/muesli/reflow/wordwrap"
+	"github.com/muesli/reflow/wrap"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+var tagColors = map[string]string{
+	"red":     "1",
+	"green":   "2",
+	"yellow":  "3",
+	"blue":    "4",
+	"magenta": "5",
+	"cyan":    "6",
+}
+
+func tagColor(color string) lipgloss.Color {
+	if c, ok := tagColors[color]; ok {
+		return lipgloss.Color(c)
+	}
+
+	if color == "" {
+		return lipgloss.Color("8")
+	}
+
+	return lipgloss.Color(color)
+}
+
+// metadataValue returns the plain text value of a metadata item, used when copying it
+func metadataValue(metadata sunbeam.Metadata) string {
+	if len(metadata.Tags) == 0 {
+		if metadata.Text == "" {
+			return metadata.Url
+		}
+		return metadata.Text
+	}
+
+	tags := make([]string, len(metadata.Tags))
+	for i, tag := range metadata.Tags {
+		tags[i] = tag.Text
+	}
+
+	return strings.Join(tags, ", ")
+}
+
+// renderMetadata renders the metadata as a panel of aligned label/value rows
+func renderMetadata(metadata []sunbeam.Metadata, width int) string {
+	if len(metadata) == 0 || width < 4 {
+		return ""
+	}
+
+	var labelWidth int
+	for _, item := range metadata {
+		labelWidth = max(labelWidth, lipgloss.Width(item.Label))
+	}
+	labelWidth = min(labelWidth, (width-4)/3)
+	valueWidth := max(width-4-labelWidth-2, 1)
+
+	labelStyle := lipgloss.NewStyle().Faint(true).Width(labelWidth).MarginRight(2)
+	rows := []string{lipgloss.NewStyle().Faint(true).Render(strings.Repeat("─", width-4))}
+	for _, item := range metadata {
+		var value string
+		switch {
+		case len(item.Tags) > 0:
+			var lines []string
+			var line string
+			for _, tag := range item.Tags {
+				tag := lipgloss.NewStyle().
+					Background(tagColor(tag.Color)).
+					Foreground(lipgloss.Color("0")).
+					Padding(0, 1).
+					Render(tag.Text)
+
+				if line != "" && lipgloss.Width(line)+1+lipgloss.Width(tag) > valueWidth {
+					lines = append(lines, line)
+					line = ""
+				}
+
+				if line != "" {
+					line += " "
+				}
+				line += tag
+			}
+			value = strings.Join(append(lines, line), "\n")
+		case item.Url != "":
+			text := item.Text
+			if text == "" {
+				text = item.Url
+			}
+
+			lines := strings.Split(wrap.String(wordwrap.String(text, valueWidth), valueWidth), "\n")
+			for i, line := range lines {
+				lines[i] = ansi.SetHyperlink(item.Url) + lipgloss.NewStyle().Underline(true).Foreground(lipgloss.Color("4")).Render(line) + ansi.ResetHyperlink()
+			}
+			value = strings.Join(lines, "\n")
+		default:
+			value = wrap.String(wordwrap.String(item.Text, valueWidth), valueWidth)
+		}
+
+		label := labelStyle.Render(wrap.String(item.Label, labelWidth))
+		rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, label, value))
+	}
+
+	return lipgloss.NewStyle().Padding(0, 2).Render(strings.Join(rows, "\n"))
+}
+
+// metadataActions returns the actions allowing the user to copy the metadata values, or open their links
+func metadataActions(metadata []sunbeam.Metadata) []sunbeam.Action {
+	var actions []sunbeam.Action
+	for _, item := range metadata {
+		if item.Url != "" {
+			actions = append(actions, sunbeam.Action{
+				Title: fmt.Sprintf("Open %s", item.Label),
+				Type:  sunbeam.ActionTypeOpen,
+				Open: &sunbeam.OpenAction{
+					Url: item.Url,
+				},
+			})
+		}
+
+		actions = append(actions, sunbeam.Action{
+			Title: fmt.Sprintf("Copy %s", item.Label),
+			Type:  sunbeam.ActionTypeCopy,
+			Copy: &sunbeam.CopyAction{
+				Text: metadataValue(item),
+			},
+		})
+	}
+
+	return actions
+}
@@ -1,7 +1,12 @@
 package tui
 
 import (
+	"os"
+	"sync"
+
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pomdtr/sunbeam/internal/extensions"
+	"github.com/pomdtr/sunbeam/internal/images"
 )
 
 func PopPageCmd() tea.Msg {
@@ -61,11 +66,42 @@ func (m *Paginator) Init() tea.Cmd {
 	return m.pages[0].Init()
 }
 
+// imageLoadedMsg is sent once an image requested while rendering a page is loaded, the pages displaying it render it again
+type imageLoadedMsg struct {
+	src string
+	err error
+}
+
+// loadImages loads the images requested since the last update, outside of the event loop
+func loadImages() tea.Cmd {
+	var cmds []tea.Cmd
+	for _, src := range images.Requests() {
+		src := src
+		cmds = append(cmds, func() tea.Msg {
+			return imageLoadedMsg{src: src, err: images.Load(src)}
+		})
+	}
+
+	return tea.Batch(cmds...)
+}
+
 func (m *Paginator) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	model, cmd := m.update(msg)
+	return model, tea.Batch(cmd, loadImages())
+}
+
+func (m *Paginator) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.Type {
 		case tea.KeyCtrlC:
+			// let the runner interrupt its command instead of exiting
+			if len(m.pages) > 0 {
+				if runner, ok := m.pages[len(m.pages)-1].(*Runner); ok && runner.IsRunning() {
+					break
+				}
+			}
+
 			m.hidden = true
 			return m, tea.Quit
 		}
@@ -89,6 +125,18 @@ func (m *Paginator) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case ExitMsg:
 		m.hidden = true
 		return m, tea.Quit
+	case commandErrorMsg:
+		return m, m.Push(NewErrorPage(msg.err))
+	case thenMsg:
+		model, cmd := m.update(msg.msg)
+		return model, tea.Batch(cmd, msg.next)
+	case imageLoadedMsg, clockMsg:
+		// the pages below the current one may display the image or the dates too
+		cmds := make([]tea.Cmd, len(m.pages))
+		for i, page := range m.pages {
+			m.pages[i], cmds[i] = page.Update(msg)
+		}
+		return m, tea.Batch(cmds...)
 	}
 
 	// Update the current page
@@ -151,9 +199,31 @@ func (m *Paginator) Pop() tea.Cmd {
 }
 
 func Draw(page Page) error {
+	// images are transmitted to the terminal between two frames of the renderer
+	output := &lockedFile{File: os.Stdout}
+	images.SetOutput(output)
+
 	paginator := NewPaginator(page)
-	p := tea.NewProgram(paginator, tea.WithAltScreen())
+	p := tea.NewProgram(paginator, tea.WithAltScreen(), tea.WithOutput(output))
+	defer extensions.StopAll()
 
 	_, err := p.Run()
 	return err
 }
+
+// lockedFile serializes the writes to the terminal, it is still a file so that the program can detect the terminal
+type lockedFile struct {
+	*os.File
+	mu sync.Mutex
+}
+
+func (f *lockedFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.File.Write(p)
+}
+
+func (f *lockedFile) WriteString(s string) (int, error) {
+	return f.Write([]byte(s))
+}
@@ -1,22 +1,18 @@
 package tui
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"sort"
-	"strings"
 
-	"github.com/atotto/clipboard"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/muesli/termenv"
 	"github.com/pomdtr/sunbeam/internal/config"
 	"github.com/pomdtr/sunbeam/internal/extensions"
 	"github.com/pomdtr/sunbeam/internal/history"
-	"github.com/pomdtr/sunbeam/internal/utils"
 	"github.com/pomdtr/sunbeam/pkg/sunbeam"
 )
 
@@ -30,16 +26,32 @@ type RootList struct {
 	config    config.Config
 	history   history.History
 	generator func() (config.Config, []sunbeam.ListItem, error)
+	executor  ActionExecutor
 }
 
 type ReloadMsg struct{}
 
 func NewRootList(title string, history history.History, generator func() (config.Config, []sunbeam.ListItem, error)) *RootList {
-	return &RootList{
+	c := &RootList{
 		title:     title,
 		history:   history,
 		generator: generator,
 	}
+
+	c.executor = ActionExecutor{
+		Extension:       c.loadExtension,
+		SavePreferences: c.savePreferences,
+		ShowForm:        c.showForm,
+		HideForm: func() {
+			c.form = nil
+		},
+		Reload: func(map[string]any) tea.Cmd {
+			return tea.Batch(c.list.SetIsLoading(true), c.Reload())
+		},
+		Focus: c.Focus,
+	}
+
+	return c
 }
 
 func (c *RootList) Init() tea.Cmd {
@@ -62,6 +74,10 @@ func (c *RootList) Reload() tea.Cmd {
 	} else {
 		c.list = NewList(rootItems...)
 		c.list.SetEmptyText("No items")
+		// frequently launched items win the ties
+		c.list.SetLess(func(i, j sunbeam.ListItem) bool {
+			return c.history.Score(i.Id) > c.history.Score(j.Id)
+		})
 		c.list.SetSize(c.width, c.height)
 
 		return c.list.Init()
@@ -101,10 +117,19 @@ func (c *RootList) SetError(err error) tea.Cmd {
 
 func (c *RootList) Update(msg tea.Msg) (Page, tea.Cmd) {
 	switch msg := msg.(type) {
+	case imageLoadedMsg, clockMsg:
+		// the list is rendered again even if a form hides it
+		if c.list != nil {
+			page, cmd := c.list.Update(msg)
+			c.list = page.(*List)
+			return c, cmd
+		}
+
+		return c, nil
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "esc":
-			if c.form != nil {
+			if c.form != nil && !c.form.IsPicking() {
 				c.form = nil
 				return c, c.list.Focus()
 			}
@@ -138,288 +163,9 @@ func (c *RootList) Update(msg tea.Msg) (Page, tea.Cmd) {
 			return c, c.SetError(err)
 		}
 
-		switch msg.Type {
-		case sunbeam.ActionTypeRun:
-			extensionConfig := c.config.Extensions[msg.Run.Extension]
-			extension, err := extensions.LoadExtension(extensionConfig.Origin)
-			if err != nil {
-				return c, c.SetError(fmt.Errorf("failed to load extension: %w", err))
-			}
-
-			preferences := extensionConfig.Preferences
-			if preferences == nil {
-				preferences = make(map[string]any)
-			}
-
-			envs, err := ExtractPreferencesFromEnv(msg.Run.Extension, extension)
-			if err != nil {
-				return c, c.SetError(err)
-			}
-			for name, value := range envs {
-				preferences[name] = value
-			}
-
-			missingPreferences := FindMissingPreferences(extension.Manifest.Preferences, preferences)
-			for _, preference := range missingPreferences {
-				if preference.Optional {
-					continue
-				}
-
-				c.form = NewForm(func(values map[string]any) tea.Msg {
-					if extensionConfig.Preferences == nil {
-						extensionConfig.Preferences = make(map[string]any)
-					}
-
-					for k, v := range values {
-						extensionConfig.Preferences[k] = v
-					}
-
-					c.config.Extensions[msg.Run.Extension] = extensionConfig
-					if config.Path == "" {
-						return msg
-					}
-
-					if err := c.config.Save(); err != nil {
-						return err
-					}
-
-					return msg
-				}, missingPreferences...)
-
-				c.form.SetSize(c.width, c.height)
-				return c, c.form.Init()
-			}
-
-			command, ok := extension.Command(msg.Run.Command)
-			if !ok {
-				return c, c.SetError(fmt.Errorf("command %s not found", msg.Run.Command))
-			}
-
-			missingParams := FindMissingInputs(command.Params, msg.Run.Params)
-			for _, param := range missingParams {
-				if param.Optional {
-					continue
-				}
-
-				c.form = NewForm(func(values map[string]any) tea.Msg {
-					params := make(map[string]any)
-					for k, v := range msg.Run.Params {
-						params[k] = v
-					}
-
-					for k, v := range values {
-						params[k] = v
-					}
-
-					props := msg.Run
-					props.Params = params
-					return sunbeam.Action{
-						Title: msg.Title,
-						Type:  sunbeam.ActionTypeRun,
-						Run:   props,
-					}
-				}, missingParams...)
-
-				c.form.SetSize(c.width, c.height)
-				return c, c.form.Init()
-			}
-			c.form = nil
-
-			input := sunbeam.Payload{
-				Command:     command.Name,
-				Params:      make(map[string]any),
-				Preferences: preferences,
-			}
-
-			for k, v := range msg.Run.Params {
-				input.Params[k] = v
-			}
-
-			switch command.Mode {
-			case sunbeam.CommandModeSearch, sunbeam.CommandModeFilter, sunbeam.CommandModeDetail:
-				runner := NewRunner(extension, input)
-				return c, PushPageCmd(runner)
-			case sunbeam.CommandModeSilent:
-				return c, func() tea.Msg {
-					output, err := extension.Output(input)
-					if err != nil {
-						return PushPageMsg{NewErrorPage(err)}
-					}
-
-					if msg.Run.Exit {
-						return ExitMsg{}
-					}
-
-					if len(output) > 0 {
-						output = bytes.Trim(output, "\n")
-						rows := strings.Split(string(output), "\n")
-						return ShowNotificationMsg{rows[len(rows)-1]}
-					}
-
-					return nil
-				}
-			case sunbeam.CommandModeTTY:
-				cmd, err := extension.Cmd(input)
-
-				if err != nil {
-					c.err = NewErrorPage(err)
-					c.err.SetSize(c.width, c.height)
-					return c, c.err.Init()
-				}
-
-				return c, tea.ExecProcess(cmd, func(err error) tea.Msg {
-					if err != nil {
-						return PushPageMsg{NewErrorPage(err)}
-					}
-
-					if msg.Run.Exit {
-						return ExitMsg{}
-					}
-
-					termenv.DefaultOutput().SetWindowTitle(c.title)
-					return c.list.Focus()
-				})
-			}
-		case sunbeam.ActionTypeCopy:
-			return c, func() tea.Msg {
-				if err := clipboard.WriteAll(msg.Copy.Text); err != nil {
-					return err
-				}
-
-				if msg.Copy.Exit {
-					return ExitMsg{}
-				}
-
-				return ShowNotificationMsg{"Copied!"}
-			}
-		case sunbeam.ActionTypeEdit:
-			editCmd := exec.Command("sunbeam", "edit", msg.Edit.Path)
-			return c, tea.ExecProcess(editCmd, func(err error) tea.Msg {
-				if err != nil {
-					return err
-				}
-
-				if msg.Edit.Reload {
-					return c.Reload()
-				}
-
-				if msg.Edit.Exit {
-					return ExitMsg{}
-				}
-
-				return nil
-			})
-		case sunbeam.ActionTypeConfig:
-			extensionConfig, ok := c.config.Extensions[msg.Config.Extension]
-			if !ok {
-				return c, c.SetError(fmt.Errorf("extension %s not found", msg.Config.Extension))
-			}
-
-			extension, err := extensions.LoadExtension(extensionConfig.Origin)
-			if err != nil {
-				return c, c.SetError(fmt.Errorf("failed to load extension %s", msg.Config.Extension))
-			}
-
-			inputs := make([]sunbeam.Input, 0)
-			for _, input := range extension.Manifest.Preferences {
-				if preference := extensionConfig.Preferences[input.Name]; preference != nil {
-					input.Default = preference
-				}
-				input.Optional = false
-				inputs = append(inputs, input)
-			}
-
-			c.form = NewForm(func(values map[string]any) tea.Msg {
-				c.form = nil
-				extensionConfig.Preferences = values
-				c.config.Extensions[msg.Config.Extension] = extensionConfig
-				if err := c.config.Save(); err != nil {
-					return err
-				}
-
-				return nil
-			}, inputs...)
-			c.form.SetSize(c.width, c.height)
-			return c, c.form.Init()
-		case sunbeam.ActionTypeExec:
-			cmd := exec.Command("sh", "-c", msg.Exec.Command)
-			cmd.Dir = msg.Exec.Dir
-			if strings.HasPrefix(cmd.Dir, "~") {
-				homeDir, err := os.UserHomeDir()
-				if err != nil {
-					return c, c.SetError(err)
-				}
-
-				cmd.Dir = filepath.Join(homeDir, strings.TrimPrefix(cmd.Dir, "~"))
-			}
-
-			if !filepath.IsAbs(cmd.Dir) {
-				wd, err := os.Getwd()
-				if err != nil {
-					return c, c.SetError(err)
-				}
-
-				cmd.Dir = filepath.Join(wd, cmd.Dir)
-			}
-
-			if !msg.Exec.Interactive {
-				return c, func() tea.Msg {
-					output, err := cmd.Output()
-					if err != nil {
-						return err
-					}
-
-					if msg.Exec.Exit {
-						return ExitMsg{}
-					}
-
-					if len(output) > 0 {
-						output = bytes.Trim(output, "\n")
-						rows := strings.Split(string(output), "\n")
-						return ShowNotificationMsg{rows[len(rows)-1]}
-					}
-
-					return nil
-				}
-			}
-
-			return c, tea.ExecProcess(cmd, func(err error) tea.Msg {
-				if err != nil {
-					return err
-				}
-
-				if msg.Exec.Exit {
-					return ExitMsg{}
-				}
-
-				termenv.DefaultOutput().SetWindowTitle(c.title)
-				return nil
-			})
-		case sunbeam.ActionTypeOpen:
-			return c, func() tea.Msg {
-				if msg.Open.Url != "" {
-					if err := utils.Open(msg.Open.Url); err != nil {
-						return err
-					}
-
-					return ExitMsg{}
-				} else if msg.Open.Path != "" {
-					if err := utils.Open(fmt.Sprintf("file://%s", msg.Open.Path)); err != nil {
-						return err
-					}
-
-					return ExitMsg{}
-				} else {
-					return fmt.Errorf("invalid target")
-				}
-			}
-		case sunbeam.ActionTypeExit:
-			return c, ExitCmd
-		case sunbeam.ActionTypeReload:
-			return c, tea.Sequence(c.err.SetIsLoading(true), c.Reload())
-		default:
-			return c, nil
-		}
+		return c, c.executor.Execute(msg)
+	case nextActionsMsg:
+		return c, msg.resume(c.executor)
 	case error:
 		c.err = NewErrorPage(msg)
 		c.err.SetSize(c.width, c.height)
@@ -448,6 +194,59 @@ func (c *RootList) Update(msg tea.Msg) (Page, tea.Cmd) {
 	return c, nil
 }
 
+func (c *RootList) loadExtension(alias string) (extensions.Extension, map[string]any, error) {
+	return loadExtension(c.config, alias)
+}
+
+func (c *RootList) savePreferences(alias string, extension extensions.Extension, values map[string]any) error {
+	return savePreferences(c.config, alias, extension, values)
+}
+
+// loadExtension loads an extension of the config, with its preferences
+func loadExtension(cfg config.Config, alias string) (extensions.Extension, map[string]any, error) {
+	extensionConfig, ok := cfg.Extensions[alias]
+	if !ok {
+		return extensions.Extension{}, nil, fmt.Errorf("extension %s not found", alias)
+	}
+
+	extension, err := extensions.LoadExtension(extensionConfig.Origin)
+	if err != nil {
+		return extensions.Extension{}, nil, fmt.Errorf("failed to load extension: %w", err)
+	}
+
+	preferences, err := ResolvePreferences(alias, extension, extensionConfig)
+	if err != nil {
+		return extensions.Extension{}, nil, err
+	}
+
+	return extension, preferences, nil
+}
+
+// savePreferences stores the secret preferences of the extension in the keyring, and the other ones in the config file
+func savePreferences(cfg config.Config, alias string, extension extensions.Extension, values map[string]any) error {
+	if err := StoreSecretPreferences(alias, extension, values); err != nil {
+		return err
+	}
+
+	extensionConfig := cfg.Extensions[alias]
+	if extensionConfig.Preferences == nil {
+		extensionConfig.Preferences = make(map[string]any)
+	}
+
+	for k, v := range values {
+		extensionConfig.Preferences[k] = v
+	}
+
+	cfg.Extensions[alias] = extensionConfig
+	return cfg.Save()
+}
+
+func (c *RootList) showForm(form *Form) tea.Cmd {
+	c.form = form
+	c.form.SetSize(c.width, c.height)
+	return tea.Sequence(c.form.Init(), c.form.Focus())
+}
+
 func (c *RootList) View() string {
 	if c.err != nil {
 		return c.err.View()
@@ -6,26 +6,88 @@ import (
 	"errors"
 	"fmt"
 	"os/exec"
+	"time"
 
-	"github.com/acarl005/stripansi"
-	"github.com/atotto/clipboard"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/muesli/termenv"
+	"github.com/pomdtr/sunbeam/internal/cache"
+	"github.com/pomdtr/sunbeam/internal/config"
 	"github.com/pomdtr/sunbeam/internal/extensions"
 	"github.com/pomdtr/sunbeam/internal/schemas"
-	"github.com/pomdtr/sunbeam/internal/utils"
 	"github.com/pomdtr/sunbeam/pkg/sunbeam"
 )
 
+// errInterrupted is the cause of the cancellation when the user interrupts a command
+var errInterrupted = errors.New("interrupted by user")
+
+// runningHintDelay is the time after which the user is told how to cancel a command
+const runningHintDelay = 2 * time.Second
+
+type runningMsg struct {
+	ctx context.Context
+}
+
+// defaultDebounce is the time to wait after the last keystroke before running a search command
+const defaultDebounce = 500 * time.Millisecond
+
+// maxCachedQueries limits the number of search results kept in memory
+const maxCachedQueries = 100
+
+// queryChangeMsg is sent when the user stopped typing
+type queryChangeMsg string
+
+// listMsg contains the output of a list command
+type listMsg struct {
+	ctx  context.Context
+	key  string
+	list sunbeam.List
+	// revalidate is set when the list replaces a cached version of itself
+	revalidate bool
+}
+
+// gridMsg contains the output of a grid command
+type gridMsg struct {
+	ctx  context.Context
+	grid sunbeam.Grid
+}
+
+// nextPageMsg contains the next page of items of a list, key identifies the input of the list
+type nextPageMsg struct {
+	ctx  context.Context
+	key  string
+	list sunbeam.List
+	err  error
+}
+
 type Runner struct {
 	embed         Page
 	form          *Form
 	width, height int
-	cancel        context.CancelFunc
+	cancel        context.CancelCauseFunc
+	runCtx        context.Context
+	streamCtx     context.Context
+
+	// pagination does not block the list, it is cancelled when the list is reloaded
+	cancelPage context.CancelFunc
+	pageCtx    context.Context
 
 	extension extensions.Extension
 	command   sunbeam.CommandSpec
 	input     sunbeam.Payload
+
+	// cache contains the outputs of the search command, indexed by cacheKey
+	cache map[string]sunbeam.List
+
+	// hidden is set while another page is pushed on top of the runner.
+	// A reload requested by a sequence in the meantime is delayed until the runner is displayed again.
+	hidden bool
+	stale  bool
+
+	executor ActionExecutor
+
+	// SavePreferences persists the preferences submitted through a form.
+	// If nil, they are only used until sunbeam exits.
+	SavePreferences func(preferences map[string]any) error
 }
 
 func NewRunner(extension extensions.Extension, input sunbeam.Payload) *Runner {
@@ -35,6 +97,7 @@ func NewRunner(extension extensions.Extension, input sunbeam.Payload) *Runner {
 		switch command.Mode {
 		case sunbeam.CommandModeSearch, sunbeam.CommandModeFilter:
 			list := NewList()
+			list.SetMultiSelect(true)
 			list.SetEmptyText("Loading...")
 			if input.Query != "" {
 				list.SetQuery(input.Query)
@@ -43,6 +106,10 @@ func NewRunner(extension extensions.Extension, input sunbeam.Payload) *Runner {
 			embed = list
 		case sunbeam.CommandModeDetail:
 			embed = NewDetail("")
+		case sunbeam.CommandModeGrid:
+			grid := NewGrid()
+			grid.SetEmptyText("Loading...")
+			embed = grid
 		default:
 			embed = NewErrorPage(fmt.Errorf("invalid view type"))
 		}
@@ -50,12 +117,108 @@ func NewRunner(extension extensions.Extension, input sunbeam.Payload) *Runner {
 		embed = NewErrorPage(fmt.Errorf("command %s not found", input.Command))
 	}
 
-	return &Runner{
+	c := &Runner{
 		embed:     embed,
 		extension: extension,
 		command:   command,
 		input:     input,
 	}
+
+	c.executor = ActionExecutor{
+		Extension:       c.loadExtension,
+		SavePreferences: c.savePreferences,
+		Source: func() extensions.Extension {
+			return c.extension
+		},
+		ShowForm: c.showForm,
+		HideForm: func() {
+			c.form = nil
+		},
+		Reload: func(params map[string]any) tea.Cmd {
+			c.cache = nil
+			if c.input.Params == nil {
+				c.input.Params = make(map[string]any)
+			}
+
+			for k, v := range params {
+				c.input.Params[k] = v
+			}
+
+			if c.hidden {
+				c.stale = true
+				return nil
+			}
+
+			return c.Reload()
+		},
+		Focus: c.Focus,
+	}
+
+	return c
+}
+
+// loadExtension returns the extension of the page when the alias is empty, the other ones are loaded from the config
+func (c *Runner) loadExtension(alias string) (extensions.Extension, map[string]any, error) {
+	if alias == "" {
+		return c.extension, c.input.Preferences, nil
+	}
+
+	cfg, err := config.Load(config.Path)
+	if err != nil {
+		return extensions.Extension{}, nil, err
+	}
+
+	return loadExtension(cfg, alias)
+}
+
+// savePreferences updates the preferences used to run the commands, and persists them if possible
+func (c *Runner) savePreferences(alias string, extension extensions.Extension, values map[string]any) error {
+	if alias != "" {
+		cfg, err := config.Load(config.Path)
+		if err != nil {
+			return err
+		}
+
+		return savePreferences(cfg, alias, extension, values)
+	}
+
+	preferences := make(map[string]any)
+	for k, v := range c.input.Preferences {
+		preferences[k] = v
+	}
+
+	for k, v := range values {
+		preferences[k] = v
+	}
+	c.input.Preferences = preferences
+
+	if c.SavePreferences != nil {
+		return c.SavePreferences(values)
+	}
+
+	return nil
+}
+
+func (c *Runner) showForm(form *Form) tea.Cmd {
+	c.form = form
+	c.form.SetSize(c.width, c.height)
+	return tea.Sequence(c.form.Init(), c.form.Focus())
+}
+
+func (c *Runner) SetStatus(status string) {
+	switch page := c.embed.(type) {
+	case *Detail:
+		page.SetStatus(status)
+	case *List:
+		page.SetStatus(status)
+	case *Grid:
+		page.SetStatus(status)
+	}
+}
+
+// IsRunning reports whether a command is in progress and can be interrupted
+func (c *Runner) IsRunning() bool {
+	return c.runCtx != nil && c.runCtx.Err() == nil
 }
 
 func (c *Runner) SetIsLoading(isLoading bool) tea.Cmd {
@@ -64,6 +227,8 @@ func (c *Runner) SetIsLoading(isLoading bool) tea.Cmd {
 		return page.SetIsLoading(isLoading)
 	case *List:
 		return page.SetIsLoading(isLoading)
+	case *Grid:
+		return page.SetIsLoading(isLoading)
 	}
 
 	return nil
@@ -75,15 +240,26 @@ func (c *Runner) Init() tea.Cmd {
 }
 
 func (c *Runner) Focus() tea.Cmd {
+	c.hidden = false
 	if c.embed == nil {
 		return nil
 	}
 	termenv.DefaultOutput().SetWindowTitle(fmt.Sprintf("%s - %s", c.command.Title, c.extension.Manifest.Title))
+
+	if c.stale {
+		c.stale = false
+		c.cache = nil
+		return tea.Batch(c.embed.Focus(), c.Reload())
+	}
+
 	return c.embed.Focus()
 }
 
 func (c *Runner) Blur() tea.Cmd {
-	c.cancel()
+	c.hidden = true
+	if c.cancel != nil {
+		c.cancel(nil)
+	}
 	return nil
 }
 
@@ -102,8 +278,13 @@ func (c *Runner) Update(msg tea.Msg) (Page, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
+		case "ctrl+c":
+			if c.IsRunning() {
+				c.cancel(errInterrupted)
+				return c, nil
+			}
 		case "esc":
-			if c.form != nil {
+			if c.form != nil && !c.form.IsPicking() {
 				c.form = nil
 				return c, c.embed.Focus()
 			}
@@ -123,6 +304,7 @@ func (c *Runner) Update(msg tea.Msg) (Page, tea.Cmd) {
 				if err != nil {
 					return err
 				}
+				c.extension.Stop()
 				c.extension = extension
 
 				return ReloadMsg{}
@@ -133,184 +315,149 @@ func (c *Runner) Update(msg tea.Msg) (Page, tea.Cmd) {
 				if err != nil {
 					return err
 				}
+				c.extension.Stop()
 				c.extension.Manifest = manifest
 
 				return ReloadMsg{}
 			}
 		}
 	case ReloadMsg:
+		c.cache = nil
 		return c, c.Reload()
-	case Page:
-		c.embed = msg
-		c.embed.SetSize(c.width, c.height)
-		return c, c.embed.Init()
-	case sunbeam.Action:
-		switch msg.Type {
-		case sunbeam.ActionTypeRun:
-			command, ok := c.extension.Command(msg.Run.Command)
-			if !ok {
-				c.embed = NewErrorPage(fmt.Errorf("command %s not found", msg.Run.Command))
-				c.embed.SetSize(c.width, c.height)
-				return c, c.embed.Init()
-			}
-
-			missing := FindMissingInputs(command.Params, msg.Run.Params)
-			for _, param := range missing {
-				if param.Optional {
-					continue
-				}
-
-				c.form = NewForm(func(values map[string]any) tea.Msg {
-					params := make(map[string]any)
-					for k, v := range msg.Run.Params {
-						params[k] = v
-					}
-
-					for k, v := range values {
-						params[k] = v
-					}
-
-					props := msg.Run
-					props.Params = params
-
-					return sunbeam.Action{
-						Title:  msg.Title,
-						Type:   sunbeam.ActionTypeRun,
-						Run:    props,
-						Reload: msg.Reload,
-					}
-				}, missing...)
-
-				c.form.SetSize(c.width, c.height)
-				return c, tea.Sequence(c.form.Init(), c.form.Focus())
-			}
-			c.form = nil
+	case queryChangeMsg:
+		if string(msg) != c.input.Query {
+			return c, nil
+		}
 
-			input := sunbeam.Payload{
-				Command:     msg.Run.Command,
-				Preferences: c.input.Preferences,
-				Params:      make(map[string]any),
-			}
+		if list, ok := c.embed.(*List); ok {
+			list.SetEmptyText("Loading...")
+		}
+		return c, c.Reload()
+	case listMsg:
+		// only the output of the latest command is displayed
+		if msg.ctx != c.runCtx {
+			return c, nil
+		}
 
-			for k, v := range msg.Run.Params {
-				input.Params[k] = v
+		if c.command.Mode == sunbeam.CommandModeSearch {
+			if c.cache == nil || len(c.cache) >= maxCachedQueries {
+				c.cache = make(map[string]sunbeam.List)
 			}
+			c.cache[msg.key] = msg.list
+		}
 
-			switch command.Mode {
-			case sunbeam.CommandModeSearch, sunbeam.CommandModeFilter, sunbeam.CommandModeDetail:
-				runner := NewRunner(c.extension, input)
-
-				return c, PushPageCmd(runner)
-			case sunbeam.CommandModeSilent:
-				return c, func() tea.Msg {
-					_, err := c.extension.Output(input)
-
-					if err != nil {
-						return PushPageMsg{NewErrorPage(err)}
-					}
-
-					if msg.Run.Reload {
-						return ReloadMsg{}
-					}
+		// keep the selection when the fresh list replaces the cached one
+		list, ok := c.embed.(*List)
+		if !msg.revalidate || !ok {
+			return c, c.setList(msg.list)
+		}
 
-					if msg.Run.Exit {
-						return ExitMsg{}
-					}
+		selection, ok := list.Selection()
+		cmd := c.setList(msg.list)
+		if ok {
+			list.Select(ListItem(selection).ID())
+		}
 
-					return nil
-				}
-			case sunbeam.CommandModeTTY:
-				cmd, err := c.extension.Cmd(input)
-				if err != nil {
-					c.embed = NewErrorPage(err)
-					c.embed.SetSize(c.width, c.height)
-					return c, c.embed.Init()
-				}
+		return c, cmd
+	case gridMsg:
+		// only the output of the latest command is displayed
+		if msg.ctx != c.runCtx {
+			return c, nil
+		}
 
-				return c, tea.ExecProcess(cmd, func(err error) tea.Msg {
-					if err != nil {
-						return PushPageMsg{NewErrorPage(err)}
-					}
+		return c, c.setGrid(msg.grid)
+	case runningMsg:
+		if msg.ctx != c.runCtx || !c.IsRunning() {
+			return c, nil
+		}
 
-					if msg.Run.Reload {
-						c.embed.Focus()
-						return ReloadMsg{}
-					}
+		c.SetStatus("Still running… press ctrl+c to cancel")
+		return c, nil
+	case nextPageMsg:
+		// pages of a replaced list or of a previous query are dropped
+		list, ok := c.embed.(*List)
+		if msg.ctx != c.pageCtx || msg.key != cacheKey(c.input) || !ok {
+			return c, nil
+		}
 
-					if msg.Run.Exit {
-						return ExitMsg{}
-					}
+		// the pages already loaded are kept, the page is requested again when the user reaches the end of the list
+		if msg.err != nil {
+			list.SetHasMore(true)
+			c.SetStatus(fmt.Sprintf("Failed to load more items: %s", msg.err))
+			return c, nil
+		}
 
-					termenv.DefaultOutput().SetWindowTitle(fmt.Sprintf("%s - %s", c.command.Title, c.extension.Manifest.Title))
-					return c.embed.Focus()
-				})
+		var items []sunbeam.ListItem
+		for _, item := range msg.list.Items {
+			if list.hasItem(ListItem(item).ID()) {
+				continue
 			}
-		case sunbeam.ActionTypeEdit:
-			editCmd := exec.Command("sunbeam", "edit", msg.Edit.Path)
-			return c, tea.ExecProcess(editCmd, func(err error) tea.Msg {
-				if err != nil {
-					return err
-				}
+			items = append(items, item)
+		}
 
-				if msg.Edit.Reload {
-					c.embed.Focus()
-					return c.Reload()
-				}
+		// the cached results of the query include the pages loaded so far
+		if cached, ok := c.cache[msg.key]; ok {
+			cached.Items = append(cached.Items[:len(cached.Items):len(cached.Items)], items...)
+			cached.NextCursor = msg.list.NextCursor
+			c.cache[msg.key] = cached
+		}
 
-				if msg.Edit.Exit {
-					return ExitMsg{}
-				}
+		list.AppendItems(items...)
+		c.setNextCursor(list, msg.list.NextCursor)
+		return c, nil
+	case streamMsg:
+		if msg.ctx != c.streamCtx {
+			return c, nil
+		}
 
-				return c.embed.Focus()
-			})
-		case sunbeam.ActionTypeCopy:
-			return c, func() tea.Msg {
-				if err := clipboard.WriteAll(msg.Copy.Text); err != nil {
-					return err
-				}
+		if msg.err != nil {
+			c.embed = NewErrorPage(msg.err)
+			c.embed.SetSize(c.width, c.height)
+			return c, c.embed.Init()
+		}
 
-				if msg.Copy.Exit {
-					return ExitMsg{}
-				}
+		list, ok := c.embed.(*List)
+		if !ok {
+			return c, nil
+		}
 
-				return ShowNotificationMsg{"Copied!"}
-			}
-		case sunbeam.ActionTypeOpen:
-			return c, func() tea.Msg {
-				if msg.Open.Url != "" {
-					if err := utils.Open(msg.Open.Url); err != nil {
-						return err
-					}
-
-					return ExitMsg{}
-				} else if msg.Open.Path != "" {
-					if err := utils.Open(fmt.Sprintf("file://%s", msg.Open.Path)); err != nil {
-						return err
-					}
-
-					return ExitMsg{}
-				} else {
-					return fmt.Errorf("invalid target")
-				}
-			}
-		case sunbeam.ActionTypeExit:
-			return c, ExitCmd
-		case sunbeam.ActionTypeReload:
-			if c.input.Params == nil {
-				c.input.Params = make(map[string]any)
-			}
+		list.AppendItems(msg.items...)
+		if msg.done {
+			list.SetEmptyText("")
+			list.SetStatus("")
+			return c, list.SetIsLoading(false)
+		}
 
-			for k, v := range msg.Reload.Params {
-				c.input.Params[k] = v
-			}
+		return c, waitForStream(msg.ctx, msg.events)
+	case Page:
+		c.embed = msg
+		c.embed.SetSize(c.width, c.height)
+		return c, c.embed.Init()
+	case BatchMsg:
+		if len(msg.Actions) == 0 {
+			return c, nil
+		}
 
-			return c, c.Reload()
+		action, ok := MergeActions(msg.Actions)
+		if !ok {
+			return c, nil
 		}
 
+		return c, c.executor.Execute(action)
+	case sunbeam.Action:
+		return c, c.executor.Execute(msg)
+	case nextActionsMsg:
+		return c, msg.resume(c.executor)
+
 	case error:
 		c.embed = NewErrorPage(msg)
 		c.embed.SetSize(c.width, c.height)
 		return c, c.embed.Init()
+	case imageLoadedMsg, clockMsg:
+		// the page is rendered again even if a form hides it
+		var cmd tea.Cmd
+		c.embed, cmd = c.embed.Update(msg)
+		return c, cmd
 	}
 
 	if c.form != nil {
@@ -333,97 +480,377 @@ func (c *Runner) View() string {
 }
 
 func (c *Runner) Reload() tea.Cmd {
-	return tea.Sequence(c.SetIsLoading(true), func() tea.Msg {
-		if c.cancel != nil {
-			c.cancel()
-		}
+	if c.cancel != nil {
+		c.cancel(nil)
+	}
+	c.stopLoadingMore()
 
-		ctx, cancel := context.WithCancel(context.Background())
-		c.cancel = cancel
-		defer cancel()
+	ctx, cancel := context.WithCancelCause(context.Background())
+	c.cancel = cancel
+	c.runCtx = ctx
+	c.SetStatus("")
 
-		cmd, err := c.extension.CmdContext(ctx, c.input)
-		if err != nil {
-			return err
+	hint := tea.Tick(runningHintDelay, func(time.Time) tea.Msg {
+		return runningMsg{ctx: ctx}
+	})
+
+	// the items of a stream are displayed as they are printed, they are never cached
+	if c.command.Stream {
+		return tea.Batch(c.reloadStream(ctx, cancel), hint)
+	}
+
+	key := cacheKey(c.input)
+	ttl := time.Duration(c.command.Cache) * time.Second
+	outputKey := cache.Key(c.extension.Entrypoint, c.input)
+
+	// the cached output is displayed right away, and refreshed if it is too old
+	var revalidate bool
+	var cached tea.Cmd
+	if ttl > 0 {
+		if output, updatedAt, ok := cache.Get(outputKey); ok {
+			if msg := c.parseOutput(ctx, key, output, false); !isError(msg) {
+				_, cached = c.Update(msg)
+				if time.Since(updatedAt) < ttl {
+					cancel(nil)
+					return cached
+				}
+
+				revalidate = true
+			}
 		}
+	}
 
-		output, err := cmd.Output()
+	return tea.Batch(cached, hint, tea.Sequence(c.SetIsLoading(true), func() tea.Msg {
+		defer cancel(nil)
+
+		output, err := c.extension.OutputContext(ctx, c.input)
 		if err != nil {
-			if errors.Is(ctx.Err(), context.Canceled) {
+			// the command was replaced by a newer one
+			if errors.Is(ctx.Err(), context.Canceled) && !errors.Is(context.Cause(ctx), errInterrupted) {
 				return nil
 			}
-			var exitErr *exec.ExitError
-			if errors.As(err, &exitErr) {
-				return fmt.Errorf("command failed: %s", stripansi.Strip(string(exitErr.Stderr)))
-			}
 
 			return err
 		}
 
-		switch c.command.Mode {
-		case sunbeam.CommandModeDetail:
-			if err := schemas.ValidateDetail(output); err != nil {
+		msg := c.parseOutput(ctx, key, output, revalidate)
+		if ttl > 0 && !isError(msg) {
+			if err := cache.Set(outputKey, output); err != nil {
 				return err
 			}
+		}
 
-			var detail sunbeam.Detail
-			if err := json.Unmarshal(output, &detail); err != nil {
-				return err
+		return msg
+	}))
+}
+
+func isError(msg tea.Msg) bool {
+	_, ok := msg.(error)
+	return ok
+}
+
+// parseOutput validates the output of the command, and converts it to a message updating the page
+func (c *Runner) parseOutput(ctx context.Context, key string, output []byte, revalidate bool) tea.Msg {
+	switch c.command.Mode {
+	case sunbeam.CommandModeDetail:
+		if err := schemas.ValidateDetail(output); err != nil {
+			return err
+		}
+
+		var detail sunbeam.Detail
+		if err := json.Unmarshal(output, &detail); err != nil {
+			return err
+		}
+
+		if detail.Markdown != "" {
+			page := NewDetail(detail.Markdown, detail.Actions...)
+			page.Markdown = true
+			page.SetMetadata(detail.Metadata...)
+			return page
+		}
+
+		page := NewDetail(detail.Text, detail.Actions...)
+		page.SetMetadata(detail.Metadata...)
+		return page
+	case sunbeam.CommandModeGrid:
+		if err := schemas.ValidateGrid(output); err != nil {
+			return err
+		}
+
+		var grid sunbeam.Grid
+		if err := json.Unmarshal(output, &grid); err != nil {
+			return err
+		}
+
+		return gridMsg{ctx: ctx, grid: grid}
+	case sunbeam.CommandModeSearch, sunbeam.CommandModeFilter:
+		if err := schemas.ValidateList(output); err != nil {
+			return err
+		}
+
+		var list sunbeam.List
+		if err := json.Unmarshal(output, &list); err != nil {
+			return err
+		}
+
+		return listMsg{ctx: ctx, key: key, list: list, revalidate: revalidate}
+	default:
+		return fmt.Errorf("invalid view type")
+	}
+}
+
+// setList displays the output of a list command, reusing the current list if there is one
+func (c *Runner) setList(list sunbeam.List) tea.Cmd {
+	if page, ok := c.embed.(*List); ok {
+		page.SetItems(list.Items...)
+		page.SetIsLoading(false)
+		page.SetStatus("")
+		page.SetEmptyText(list.EmptyText)
+		page.SetActions(list.Actions...)
+		page.SetShowDetail(list.ShowDetail)
+		page.SetAutoRefreshSeconds(list.AutoRefreshSeconds)
+		c.setNextCursor(page, list.NextCursor)
+
+		if c.command.Mode == sunbeam.CommandModeSearch {
+			page.OnQueryChange = c.onQueryChange
+			page.ResetSelection()
+		}
+
+		return nil
+	}
+
+	page := NewList(list.Items...)
+	page.SetMultiSelect(true)
+	page.SetEmptyText(list.EmptyText)
+	page.SetActions(list.Actions...)
+	page.SetShowDetail(list.ShowDetail)
+	c.setNextCursor(page, list.NextCursor)
+	if c.command.Mode == sunbeam.CommandModeSearch {
+		page.OnQueryChange = c.onQueryChange
+	}
+
+	c.embed = page
+	c.embed.SetSize(c.width, c.height)
+	return c.embed.Init()
+}
+
+// setGrid displays the output of a grid command, reusing the current grid if there is one
+func (c *Runner) setGrid(grid sunbeam.Grid) tea.Cmd {
+	if page, ok := c.embed.(*Grid); ok {
+		page.SetEmptyText(grid.EmptyText)
+		page.SetColumns(grid.Columns)
+		page.SetActions(grid.Actions...)
+		page.SetItems(grid.Items...)
+		return page.SetIsLoading(false)
+	}
+
+	page := NewGrid(grid.Items...)
+	page.SetEmptyText(grid.EmptyText)
+	page.SetColumns(grid.Columns)
+	page.SetActions(grid.Actions...)
+
+	c.embed = page
+	c.embed.SetSize(c.width, c.height)
+	return c.embed.Init()
+}
+
+// onQueryChange shows the cached results of the query if there are any, or reloads the list once the user stops typing
+func (c *Runner) onQueryChange(query string) tea.Cmd {
+	c.input.Query = query
+	if list, ok := c.cache[cacheKey(c.input)]; ok {
+		// the output of the running command is not needed anymore
+		if c.cancel != nil {
+			c.cancel(nil)
+		}
+		c.runCtx = nil
+		c.stopLoadingMore()
+
+		return tea.Batch(c.SetIsLoading(false), c.setList(list))
+	}
+
+	debounce := defaultDebounce
+	if c.command.Debounce > 0 {
+		debounce = time.Duration(c.command.Debounce) * time.Millisecond
+	}
+
+	return tea.Tick(debounce, func(time.Time) tea.Msg {
+		return queryChangeMsg(query)
+	})
+}
+
+func cacheKey(input sunbeam.Payload) string {
+	key, _ := json.Marshal(map[string]any{
+		"command": input.Command,
+		"params":  input.Params,
+		"query":   input.Query,
+	})
+
+	return string(key)
+}
+
+// setNextCursor sets the cursor of the next page of the list.
+// The next page is fetched with the input of the displayed items, even if the query changed in the meantime.
+func (c *Runner) setNextCursor(list *List, cursor string) {
+	list.SetHasMore(cursor != "")
+	input := c.input
+	list.OnLoadMore = func() tea.Cmd {
+		return c.loadMore(input, cursor)
+	}
+}
+
+// loadMore runs the command again with the cursor of the next page
+func (c *Runner) loadMore(input sunbeam.Payload, cursor string) tea.Cmd {
+	// the list is being reloaded, the next page of the new list is loaded once it is displayed
+	if c.IsRunning() {
+		return nil
+	}
+
+	c.stopLoadingMore()
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancelPage = cancel
+	c.pageCtx = ctx
+
+	key := cacheKey(input)
+	input.Cursor = cursor
+
+	return func() tea.Msg {
+		defer cancel()
+
+		output, err := c.extension.OutputContext(ctx, input)
+		if err != nil {
+			// the list was reloaded in the meantime
+			if ctx.Err() != nil {
+				return nil
 			}
 
-			if detail.Markdown != "" {
-				page := NewDetail(detail.Markdown, detail.Actions...)
-				page.Markdown = true
-				return page
+			return nextPageMsg{ctx: ctx, key: key, err: err}
+		}
+
+		if err := schemas.ValidateList(output); err != nil {
+			return nextPageMsg{ctx: ctx, key: key, err: err}
+		}
+
+		var list sunbeam.List
+		if err := json.Unmarshal(output, &list); err != nil {
+			return nextPageMsg{ctx: ctx, key: key, err: err}
+		}
+
+		return nextPageMsg{ctx: ctx, key: key, list: list}
+	}
+}
+
+// stopLoadingMore cancels the page being loaded, if any
+func (c *Runner) stopLoadingMore() {
+	if c.cancelPage != nil {
+		c.cancelPage()
+	}
+	c.cancelPage = nil
+	c.pageCtx = nil
+}
+
+const maxStreamBatch = 500
+
+type streamEvent struct {
+	item sunbeam.ListItem
+	err  error
+}
+
+type streamMsg struct {
+	ctx    context.Context
+	events <-chan streamEvent
+	items  []sunbeam.ListItem
+	err    error
+	done   bool
+}
+
+// reloadStream runs the command and appends the items to the list as they are printed, one per line
+func (c *Runner) reloadStream(ctx context.Context, cancel context.CancelCauseFunc) tea.Cmd {
+	c.streamCtx = ctx
+
+	list, ok := c.embed.(*List)
+	if !ok {
+		list = NewList()
+		list.SetMultiSelect(true)
+		list.SetSize(c.width, c.height)
+		c.embed = list
+	}
+
+	list.SetItems()
+	list.SetEmptyText("Loading...")
+	list.ResetSelection()
+	if c.command.Mode == sunbeam.CommandModeSearch {
+		list.OnQueryChange = c.onQueryChange
+	}
+
+	events := make(chan streamEvent, 64)
+	go func() {
+		defer cancel(nil)
+		defer close(events)
+
+		err := c.extension.StreamContext(ctx, c.input, func(line []byte) error {
+			if err := schemas.ValidateListItem(line); err != nil {
+				return fmt.Errorf("invalid list item: %w", err)
 			}
 
-			page := NewDetail(detail.Text, detail.Actions...)
-			return page
-		case sunbeam.CommandModeSearch, sunbeam.CommandModeFilter:
-			if err := schemas.ValidateList(output); err != nil {
+			var item sunbeam.ListItem
+			if err := json.Unmarshal(line, &item); err != nil {
 				return err
 			}
 
-			var list sunbeam.List
-			if err := json.Unmarshal(output, &list); err != nil {
-				return err
+			select {
+			case events <- streamEvent{item: item}:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
 			}
+		})
 
-			var page *List
-			if embed, ok := c.embed.(*List); ok {
-				page = embed
-				page.SetItems(list.Items...)
-				page.SetIsLoading(false)
-				page.SetEmptyText(list.EmptyText)
-				page.SetActions(list.Actions...)
-				page.SetShowDetail(list.ShowDetail)
-				page.SetAutoRefreshSeconds(list.AutoRefreshSeconds)
-
-				if c.command.Mode == sunbeam.CommandModeSearch {
-					page.OnQueryChange = func(query string) tea.Cmd {
-						c.input.Query = query
-						return c.Reload()
-					}
-					page.ResetSelection()
-				}
+		if err == nil {
+			return
+		}
 
-				return nil
+		if ctx.Err() == nil {
+			select {
+			case events <- streamEvent{err: err}:
+			case <-ctx.Done():
 			}
+		} else if errors.Is(context.Cause(ctx), errInterrupted) {
+			// the context is already done, the consumer is still waiting for events
+			events <- streamEvent{err: err}
+		}
+	}()
 
-			page = NewList(list.Items...)
-			page.SetEmptyText(list.EmptyText)
-			page.SetActions(list.Actions...)
-			page.SetShowDetail(list.ShowDetail)
-			if c.command.Mode == sunbeam.CommandModeSearch {
-				page.OnQueryChange = func(query string) tea.Cmd {
-					c.input.Query = query
-					return c.Reload()
-				}
+	return tea.Batch(list.SetIsLoading(true), waitForStream(ctx, events))
+}
+
+// waitForStream blocks until items are available, then drains the ones already received
+func waitForStream(ctx context.Context, events <-chan streamEvent) tea.Cmd {
+	return func() tea.Msg {
+		msg := streamMsg{ctx: ctx, events: events}
+
+		event, ok := <-events
+		for {
+			if !ok {
+				msg.done = true
+				return msg
 			}
 
-			return page
-		default:
-			return fmt.Errorf("invalid view type")
+			if event.err != nil {
+				msg.err = event.err
+				return msg
+			}
+
+			msg.items = append(msg.items, event.item)
+			if len(msg.items) >= maxStreamBatch {
+				return msg
+			}
+
+			select {
+			case event, ok = <-events:
+				continue
+			default:
+				return msg
+			}
 		}
-	})
+	}
 }
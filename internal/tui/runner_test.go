@@ -0,0 +1,91 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pomdtr/sunbeam/internal/extensions"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+func TestRunnerNextPage(t *testing.T) {
+	extension := extensions.Extension{
+		Manifest: sunbeam.Manifest{
+			Title:    "Test",
+			Commands: []sunbeam.CommandSpec{{Name: "search", Title: "Search", Mode: sunbeam.CommandModeSearch}},
+		},
+	}
+
+	runner := NewRunner(extension, sunbeam.Payload{Command: "search", Query: "a"})
+	key := cacheKey(runner.input)
+	runner.Update(listMsg{key: key, list: sunbeam.List{Items: []sunbeam.ListItem{{Title: "a1"}}, NextCursor: "2"}})
+	runner.Update(nextPageMsg{key: key, list: sunbeam.List{Items: []sunbeam.ListItem{{Title: "a2"}}, NextCursor: "3"}})
+
+	// a page load does not block the list
+	if cmd := runner.loadMore(runner.input, "3"); cmd == nil || runner.IsRunning() {
+		t.Fatal("expected the next page to load in the background")
+	}
+	runner.stopLoadingMore()
+
+	// a failed page keeps the items loaded so far
+	runner.Update(nextPageMsg{key: key, err: errors.New("failed")})
+	if list := runner.embed.(*List); len(list.filter.items) != 2 || !list.hasMore {
+		t.Fatalf("expected the loaded items to be kept after a failed page, got %d items", len(list.filter.items))
+	}
+
+	cached := runner.cache[key]
+	if len(cached.Items) != 2 || cached.NextCursor != "3" {
+		t.Fatalf("expected the cache to contain both pages and the last cursor, got %d items and cursor %q", len(cached.Items), cached.NextCursor)
+	}
+
+	// the page of the previous query arrives after the query changed
+	runner.onQueryChange("b")
+	runner.Update(nextPageMsg{key: key, list: sunbeam.List{Items: []sunbeam.ListItem{{Title: "a3"}}}})
+	if items := runner.embed.(*List).filter.items; len(items) != 2 {
+		t.Errorf("expected the page of the previous query to be dropped, got %d items", len(items))
+	}
+	if cached := runner.cache[key]; len(cached.Items) != 2 {
+		t.Errorf("expected the cache of the previous query to be unchanged, got %d items", len(cached.Items))
+	}
+
+	// the cached results of the query are displayed with all their pages
+	runner.onQueryChange("a")
+	list := runner.embed.(*List)
+	if items := list.filter.items; len(items) != 2 || !list.hasMore {
+		t.Errorf("expected the cached pages to be displayed with more to load, got %d items", len(items))
+	}
+
+	// the next page is not loaded while the list is reloading
+	runner.runCtx = context.Background()
+	if cmd := runner.loadMore(runner.input, "3"); cmd != nil {
+		t.Error("expected the next page to wait for the reload")
+	}
+}
+
+func TestRunnerGridOutput(t *testing.T) {
+	extension := extensions.Extension{
+		Manifest: sunbeam.Manifest{
+			Title:    "Test",
+			Commands: []sunbeam.CommandSpec{{Name: "icons", Title: "Icons", Mode: sunbeam.CommandModeGrid}},
+		},
+	}
+
+	runner := NewRunner(extension, sunbeam.Payload{Command: "icons"})
+	grid := runner.embed.(*Grid)
+
+	// the output is parsed outside of the event loop, the grid is only updated once the message is handled
+	msg := runner.parseOutput(runner.runCtx, "", []byte(`{"items": [{"title": "a", "content": {"text": "a"}}]}`), false)
+	if _, ok := msg.(gridMsg); !ok {
+		t.Fatalf("expected a grid message, got %T", msg)
+	}
+
+	if len(grid.filter.items) != 0 {
+		t.Fatalf("expected the grid to be unchanged before the message is handled")
+	}
+
+	runner.Update(msg)
+	if runner.embed != grid || len(grid.filter.items) != 1 {
+		t.Errorf("expected the items to be set on the current grid")
+	}
+}
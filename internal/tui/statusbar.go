@@ -21,11 +21,17 @@ type StatusBar struct {
 	Width int
 
 	notification string
+	status       string
+	hint         string
+	selected     int
 
 	cursor   int
 	actions  []sunbeam.Action
 	filtered []sunbeam.Action
 	expanded bool
+
+	// confirming is the action waiting for the user to confirm it
+	confirming *confirmMsg
 }
 
 type ShowNotificationMsg struct {
@@ -48,6 +54,21 @@ func (c *StatusBar) SetActionsNoSelection(actions ...sunbeam.Action) {
 	c.filtered = actions
 }
 
+// SetStatus sets a message that stays visible until it is cleared, unlike notifications
+func (c *StatusBar) SetStatus(status string) {
+	c.status = status
+}
+
+// SetHint sets a message displayed when there is no other message to display
+func (c *StatusBar) SetHint(hint string) {
+	c.hint = hint
+}
+
+// SetSelectionCount shows the number of selected items when there is no other message to display
+func (c *StatusBar) SetSelectionCount(n int) {
+	c.selected = n
+}
+
 func (c *StatusBar) SetActions(actions ...sunbeam.Action) {
 	c.expanded = false
 	c.cursor = 0
@@ -75,9 +96,28 @@ func (c *StatusBar) FilterActions(query string) {
 	c.cursor = 0
 }
 
+// Confirming reports whether the status bar is waiting for the user to confirm an action.
+// Pages should forward their key presses to the status bar while it is.
+func (c StatusBar) Confirming() bool {
+	return c.confirming != nil
+}
+
 func (p StatusBar) Update(msg tea.Msg) (StatusBar, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if p.confirming != nil {
+			run := p.confirming.run
+			switch msg.String() {
+			case "y", "Y", "enter":
+				p.confirming = nil
+				return p, run
+			case "n", "N", "esc", "q":
+				p.confirming = nil
+			}
+
+			return p, nil
+		}
+
 		switch msg.String() {
 		case "tab", "right":
 			if !p.expanded {
@@ -111,17 +151,14 @@ func (p StatusBar) Update(msg tea.Msg) (StatusBar, tea.Cmd) {
 			}
 
 			action := p.filtered[p.cursor]
-			return p, func() tea.Msg {
-				return action
-			}
+			return p, func() tea.Msg { return action }
 		case "alt+enter":
 			if p.cursor != 0 || len(p.actions) < 2 {
 				break
 			}
 
-			return p, func() tea.Msg {
-				return p.actions[1]
-			}
+			action := p.actions[1]
+			return p, func() tea.Msg { return action }
 		case "ctrl+d":
 			if p.expanded {
 				break
@@ -131,12 +168,13 @@ func (p StatusBar) Update(msg tea.Msg) (StatusBar, tea.Cmd) {
 		default:
 			for _, action := range p.actions {
 				if fmt.Sprintf("alt+%s", action.Key) == msg.String() {
-					return p, func() tea.Msg {
-						return action
-					}
+					return p, func() tea.Msg { return action }
 				}
 			}
 		}
+	case confirmMsg:
+		p.confirming = &msg
+		return p, nil
 	case ShowNotificationMsg:
 		p.Reset()
 		if msg.Title == "" {
@@ -156,6 +194,7 @@ func (p StatusBar) Update(msg tea.Msg) (StatusBar, tea.Cmd) {
 }
 
 func (c *StatusBar) Reset() {
+	c.confirming = nil
 	c.expanded = false
 	c.cursor = 0
 	c.filtered = c.actions
@@ -185,9 +224,25 @@ func ActionTitle(action sunbeam.Action) string {
 }
 
 func (c StatusBar) View() string {
+	if c.confirming != nil {
+		return lipgloss.JoinVertical(lipgloss.Left, separator(c.Width), c.confirmView())
+	}
+
+	message := c.notification
+	if message == "" {
+		message = c.status
+	}
+	if message == "" && c.selected > 0 {
+		message = fmt.Sprintf("%d selected", c.selected)
+	}
+	if message == "" {
+		message = c.hint
+	}
+
 	var accessory string
 	if len(c.actions) == 0 {
-		return lipgloss.JoinVertical(lipgloss.Left, separator(c.Width), strings.Repeat(" ", c.Width))
+		blanks := strings.Repeat(" ", max(c.Width-lipgloss.Width(message)-3, 0))
+		return lipgloss.JoinVertical(lipgloss.Left, separator(c.Width), fmt.Sprintf("   %s%s", lipgloss.NewStyle().Faint(true).Render(message), blanks))
 	}
 	if c.expanded {
 		accessories := make([]string, len(c.filtered))
@@ -236,13 +291,30 @@ func (c StatusBar) View() string {
 		statusbar = fmt.Sprintf("   %s ", accessory)
 	} else {
 
-		blanks := strings.Repeat(" ", max(c.Width-lipgloss.Width(accessory)-lipgloss.Width(c.notification)-4, 0))
-		statusbar = fmt.Sprintf("   %s%s%s ", lipgloss.NewStyle().Faint(true).Render(c.notification), blanks, accessory)
+		blanks := strings.Repeat(" ", max(c.Width-lipgloss.Width(accessory)-lipgloss.Width(message)-4, 0))
+		statusbar = fmt.Sprintf("   %s%s%s ", lipgloss.NewStyle().Faint(true).Render(message), blanks, accessory)
 	}
 
 	return lipgloss.JoinVertical(lipgloss.Left, separator(c.Width), statusbar)
 }
 
+func (c StatusBar) confirmView() string {
+	action := c.confirming.action
+	message := action.Confirm.Message
+	if message == "" {
+		message = fmt.Sprintf("%s?", ActionTitle(action))
+	}
+
+	style := lipgloss.NewStyle().Bold(true)
+	if action.Confirm.Danger {
+		style = style.Foreground(lipgloss.Color("9"))
+	}
+
+	accessory := fmt.Sprintf("%s · %s", renderAction("Confirm", "y", false), renderAction("Cancel", "n", false))
+	blanks := strings.Repeat(" ", max(c.Width-lipgloss.Width(accessory)-lipgloss.Width(message)-4, 0))
+	return fmt.Sprintf("   %s%s%s ", style.Render(message), blanks, accessory)
+}
+
 func renderAction(title string, subtitle string, selected bool) string {
 	var view string
 	if subtitle != "" {
@@ -9,20 +9,25 @@ type Action struct {
 	Key   string     `json:"key,omitempty"`
 	Type  ActionType `json:"type,omitempty"`
 
-	Open   *OpenAction   `json:"-"`
-	Copy   *CopyAction   `json:"-"`
-	Run    *RunAction    `json:"-"`
-	Exec   *ExecAction   `json:"-"`
-	Edit   *EditAction   `json:"-"`
-	Config *ConfigAction `json:"-"`
-	Reload *ReloadAction `json:"-"`
+	// Confirm asks the user for confirmation before running the action
+	Confirm *Confirm `json:"confirm,omitempty"`
+
+	Open     *OpenAction     `json:"-"`
+	Copy     *CopyAction     `json:"-"`
+	Run      *RunAction      `json:"-"`
+	Exec     *ExecAction     `json:"-"`
+	Edit     *EditAction     `json:"-"`
+	Config   *ConfigAction   `json:"-"`
+	Reload   *ReloadAction   `json:"-"`
+	Sequence *SequenceAction `json:"-"`
 }
 
 func (a *Action) UnmarshalJSON(bts []byte) error {
 	var action struct {
-		Title string `json:"title,omitempty"`
-		Key   string `json:"key,omitempty"`
-		Type  string `json:"type,omitempty"`
+		Title   string          `json:"title,omitempty"`
+		Key     string          `json:"key,omitempty"`
+		Type    string          `json:"type,omitempty"`
+		Confirm json.RawMessage `json:"confirm,omitempty"`
 	}
 
 	if err := json.Unmarshal(bts, &action); err != nil {
@@ -33,6 +38,14 @@ func (a *Action) UnmarshalJSON(bts []byte) error {
 	a.Key = action.Key
 	a.Type = ActionType(action.Type)
 
+	// confirm can be set to false to disable the prompt
+	if len(action.Confirm) > 0 && string(action.Confirm) != "false" && string(action.Confirm) != "null" {
+		a.Confirm = &Confirm{}
+		if err := json.Unmarshal(action.Confirm, a.Confirm); err != nil {
+			return err
+		}
+	}
+
 	switch a.Type {
 	case ActionTypeRun:
 		a.Run = &RunAction{}
@@ -57,11 +70,102 @@ func (a *Action) UnmarshalJSON(bts []byte) error {
 	case ActionTypeConfig:
 		a.Config = &ConfigAction{}
 		return json.Unmarshal(bts, a.Config)
+	case ActionTypeSequence:
+		a.Sequence = &SequenceAction{}
+		return json.Unmarshal(bts, a.Sequence)
 	}
 
 	return nil
 }
 
+// MarshalJSON flattens the fields specific to the type of the action, the reverse of UnmarshalJSON
+func (a Action) MarshalJSON() ([]byte, error) {
+	var props any
+	switch {
+	case a.Type == ActionTypeRun && a.Run != nil:
+		props = a.Run
+	case a.Type == ActionTypeOpen && a.Open != nil:
+		props = a.Open
+	case a.Type == ActionTypeCopy && a.Copy != nil:
+		props = a.Copy
+	case a.Type == ActionTypeEdit && a.Edit != nil:
+		props = a.Edit
+	case a.Type == ActionTypeExec && a.Exec != nil:
+		props = a.Exec
+	case a.Type == ActionTypeReload && a.Reload != nil:
+		props = a.Reload
+	case a.Type == ActionTypeConfig && a.Config != nil:
+		props = a.Config
+	case a.Type == ActionTypeSequence && a.Sequence != nil:
+		props = a.Sequence
+	}
+
+	fields := make(map[string]any)
+	if props != nil {
+		bts, err := json.Marshal(props)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(bts, &fields); err != nil {
+			return nil, err
+		}
+	}
+
+	if a.Title != "" {
+		fields["title"] = a.Title
+	}
+	if a.Key != "" {
+		fields["key"] = a.Key
+	}
+	if a.Type != "" {
+		fields["type"] = a.Type
+	}
+	if a.Confirm != nil {
+		fields["confirm"] = a.Confirm
+	}
+
+	return json.Marshal(fields)
+}
+
+// Confirm is the prompt shown before running an action.
+// It can be set to true to use the default message.
+type Confirm struct {
+	Message string `json:"message,omitempty"`
+	Danger  bool   `json:"danger,omitempty"`
+}
+
+func (c *Confirm) UnmarshalJSON(bts []byte) error {
+	var enabled bool
+	if err := json.Unmarshal(bts, &enabled); err == nil {
+		*c = Confirm{}
+		return nil
+	}
+
+	type alias Confirm
+	var v alias
+	if err := json.Unmarshal(bts, &v); err != nil {
+		return err
+	}
+
+	*c = Confirm(v)
+	return nil
+}
+
+func (c Confirm) MarshalJSON() ([]byte, error) {
+	if c.Message == "" && !c.Danger {
+		return json.Marshal(true)
+	}
+
+	type alias Confirm
+	return json.Marshal(alias(c))
+}
+
+// SequenceAction runs its actions one after the other, stopping at the first failure
+type SequenceAction struct {
+	Actions []Action `json:"actions"`
+}
+
 type ConfigAction struct {
 	Extension string `json:"extension,omitempty"`
 }
@@ -82,6 +186,9 @@ type RunAction struct {
 	Params    map[string]any `json:"params,omitempty"`
 	Reload    bool           `json:"reload,omitempty"`
 	Exit      bool           `json:"exit,omitempty"`
+	// Batch allows the action to run on all the selected items at once.
+	// Each param is then passed as an array of the values of the selected items.
+	Batch bool `json:"batch,omitempty"`
 }
 
 type CopyAction struct {
@@ -104,14 +211,15 @@ type OpenAction struct {
 type ActionType string
 
 const (
-	ActionTypeRun    ActionType = "run"
-	ActionTypeOpen   ActionType = "open"
-	ActionTypeCopy   ActionType = "copy"
-	ActionTypeEdit   ActionType = "edit"
-	ActionTypeExec   ActionType = "exec"
-	ActionTypeExit   ActionType = "exit"
-	ActionTypeReload ActionType = "reload"
-	ActionTypeConfig ActionType = "config"
+	ActionTypeRun      ActionType = "run"
+	ActionTypeOpen     ActionType = "open"
+	ActionTypeCopy     ActionType = "copy"
+	ActionTypeEdit     ActionType = "edit"
+	ActionTypeExec     ActionType = "exec"
+	ActionTypeExit     ActionType = "exit"
+	ActionTypeReload   ActionType = "reload"
+	ActionTypeConfig   ActionType = "config"
+	ActionTypeSequence ActionType = "sequence"
 )
 
 type Payload struct {
@@ -120,4 +228,6 @@ type Payload struct {
 	Params      map[string]any `json:"params"`
 	Cwd         string         `json:"cwd"`
 	Query       string         `json:"query,omitempty"`
+	// Cursor is the nextCursor of the previous page, when loading more items
+	Cursor string `json:"cursor,omitempty"`
 }
@@ -3,16 +3,33 @@ package sunbeam
 type Manifest struct {
 	Title       string        `json:"title"`
 	Description string        `json:"description,omitempty"`
+	Persistent  bool          `json:"persistent,omitempty"`
+	Timeout     int           `json:"timeout,omitempty"`
+	Permissions *Permissions  `json:"permissions,omitempty"`
 	Preferences []Input       `json:"preferences,omitempty"`
 	Commands    []CommandSpec `json:"commands"`
 }
 
+type Permissions struct {
+	Network bool     `json:"network,omitempty"`
+	Read    []string `json:"read,omitempty"`
+	Write   []string `json:"write,omitempty"`
+	Env     []string `json:"env,omitempty"`
+}
+
 type CommandSpec struct {
-	Name   string      `json:"name"`
-	Title  string      `json:"title"`
-	Hidden bool        `json:"hidden,omitempty"`
-	Params []Input     `json:"params,omitempty"`
-	Mode   CommandMode `json:"mode,omitempty"`
+	Name    string      `json:"name"`
+	Title   string      `json:"title"`
+	Hidden  bool        `json:"hidden,omitempty"`
+	Params  []Input     `json:"params,omitempty"`
+	Mode    CommandMode `json:"mode,omitempty"`
+	Stream  bool        `json:"stream,omitempty"`
+	Timeout int         `json:"timeout,omitempty"`
+	// Cache is the number of seconds during which the output of the command is considered fresh.
+	// Older outputs are still displayed while the command runs again.
+	Cache int `json:"cache,omitempty"`
+	// Debounce is the delay in milliseconds between the last keystroke and the run of a search command
+	Debounce int `json:"debounce,omitempty"`
 }
 
 type Platfom string
@@ -33,6 +50,7 @@ const (
 	CommandModeSearch CommandMode = "search"
 	CommandModeFilter CommandMode = "filter"
 	CommandModeDetail CommandMode = "detail"
+	CommandModeGrid   CommandMode = "grid"
 	CommandModeTTY    CommandMode = "tty"
 	CommandModeSilent CommandMode = "silent"
 )
@@ -43,12 +61,16 @@ const (
 	InputString  InputType = "string"
 	InputBoolean InputType = "boolean"
 	InputNumber  InputType = "number"
+	InputSelect  InputType = "select"
+	InputSecret  InputType = "secret"
 )
 
 type Input struct {
-	Type     InputType `json:"type"`
-	Name     string    `json:"name"`
-	Title    string    `json:"title"`
-	Optional bool      `json:"optional,omitempty"`
-	Default  any       `json:"default,omitempty"`
+	Type           InputType `json:"type"`
+	Name           string    `json:"name"`
+	Title          string    `json:"title"`
+	Optional       bool      `json:"optional,omitempty"`
+	Default        any       `json:"default,omitempty"`
+	Options        []string  `json:"options,omitempty"`
+	OptionsCommand string    `json:"optionsCommand,omitempty"`
 }
@@ -1,29 +1,144 @@
 package sunbeam
 
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
 type List struct {
 	Items              []ListItem `json:"items,omitempty"`
 	EmptyText          string     `json:"emptyText,omitempty"`
 	ShowDetail         bool       `json:"showDetail,omitempty"`
 	AutoRefreshSeconds int        `json:"autoRefreshSeconds,omitempty"`
 	Actions            []Action   `json:"actions,omitempty"`
+	// NextCursor is sent back in the payload to fetch the next page of items.
+	// If empty, there are no more items to load.
+	NextCursor string `json:"nextCursor,omitempty"`
 }
 
 type ListItem struct {
 	Id          string         `json:"id,omitempty"`
 	Title       string         `json:"title"`
 	Subtitle    string         `json:"subtitle,omitempty"`
+	Section     string         `json:"section,omitempty"`
 	Detail      ListItemDetail `json:"detail,omitempty"`
-	Accessories []string       `json:"accessories,omitempty"`
+	Accessories []Accessory    `json:"accessories,omitempty"`
 	Actions     []Action       `json:"actions,omitempty"`
 }
 
+// MarshalJSON omits the detail of the item when it is empty
+func (i ListItem) MarshalJSON() ([]byte, error) {
+	type listItem ListItem
+	item := struct {
+		listItem
+		Detail *ListItemDetail `json:"detail,omitempty"`
+	}{listItem: listItem(i)}
+
+	if detail := i.Detail; detail.Markdown != "" || detail.Text != "" || len(detail.Metadata) > 0 {
+		item.Detail = &detail
+	}
+
+	return json.Marshal(item)
+}
+
+// Accessory is displayed on the right side of a list item.
+// It can be defined as a plain string, or as an object to customize its appearance.
+type Accessory struct {
+	Text    string `json:"text,omitempty"`
+	Icon    string `json:"icon,omitempty"`
+	Color   string `json:"color,omitempty"`
+	Tooltip string `json:"tooltip,omitempty"`
+	// Date is displayed relative to the current time, ex: "3h ago"
+	Date *time.Time `json:"date,omitempty"`
+}
+
+func (a *Accessory) UnmarshalJSON(bts []byte) error {
+	var text string
+	if err := json.Unmarshal(bts, &text); err == nil {
+		*a = Accessory{Text: text}
+		return nil
+	}
+
+	type accessory Accessory
+	var v accessory
+	if err := json.Unmarshal(bts, &v); err != nil {
+		return err
+	}
+
+	*a = Accessory(v)
+	return nil
+}
+
+func (a Accessory) MarshalJSON() ([]byte, error) {
+	if a.Icon == "" && a.Color == "" && a.Tooltip == "" && a.Date == nil {
+		return json.Marshal(a.Text)
+	}
+
+	type accessory Accessory
+	return json.Marshal(accessory(a))
+}
+
 type ListItemDetail struct {
-	Markdown string `json:"markdown,omitempty"`
-	Text     string `json:"text,omitempty"`
+	Markdown string     `json:"markdown,omitempty"`
+	Text     string     `json:"text,omitempty"`
+	Metadata []Metadata `json:"metadata,omitempty"`
+}
+
+// Metadata is a labeled value displayed below the content of a detail.
+// Either Text or Tags should be set. If Url is set, the text is displayed as a link.
+type Metadata struct {
+	Label string `json:"label"`
+	Text  string `json:"text,omitempty"`
+	Url   string `json:"url,omitempty"`
+	Tags  []Tag  `json:"tags,omitempty"`
+}
+
+type Tag struct {
+	Text  string `json:"text"`
+	Color string `json:"color,omitempty"`
+}
+
+type Grid struct {
+	Items     []GridItem `json:"items,omitempty"`
+	Columns   int        `json:"columns,omitempty"`
+	EmptyText string     `json:"emptyText,omitempty"`
+	Actions   []Action   `json:"actions,omitempty"`
+}
+
+type GridItem struct {
+	Id      string          `json:"id,omitempty"`
+	Title   string          `json:"title"`
+	Content GridItemContent `json:"content"`
+	Actions []Action        `json:"actions,omitempty"`
+}
+
+// GridItemContent is the content of a grid cell, only one of the fields should be set
+type GridItemContent struct {
+	Text  string `json:"text,omitempty"`
+	Emoji string `json:"emoji,omitempty"`
+	Image string `json:"image,omitempty"`
 }
 
 type Detail struct {
-	Actions  []Action `json:"actions,omitempty"`
-	Markdown string   `json:"markdown,omitempty"`
-	Text     string   `json:"text,omitempty"`
+	Actions  []Action   `json:"actions,omitempty"`
+	Markdown string     `json:"markdown,omitempty"`
+	Text     string     `json:"text,omitempty"`
+	Metadata []Metadata `json:"metadata,omitempty"`
+}
+
+// Error is a failure reported by an extension, with actions to help the user recover from it
+type Error struct {
+	Title   string   `json:"title"`
+	Message string   `json:"message,omitempty"`
+	Hint    string   `json:"hint,omitempty"`
+	Actions []Action `json:"actions,omitempty"`
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return e.Title
+	}
+
+	return fmt.Sprintf("%s: %s", e.Title, e.Message)
 }
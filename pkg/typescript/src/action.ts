@@ -1,6 +1,12 @@
 type ActionProps = {
   title?: string;
   key?: string;
+  confirm?: boolean | Confirm;
+};
+
+export type Confirm = {
+  message?: string;
+  danger?: boolean;
 };
 
 export type CopyAction = {
@@ -27,6 +33,7 @@ export type RunAction = {
   params?: Record<string, Param>;
   reload?: boolean;
   exit?: boolean;
+  batch?: boolean;
 } & ActionProps;
 
 export type Param =
@@ -40,14 +47,26 @@ export type ReloadAction = {
   params?: Record<string, Param>;
 } & ActionProps;
 
+export type ConfigAction = {
+  type: "config";
+  extension?: string;
+} & ActionProps;
+
 export type ExitAction = {
   type: "exit";
 } & ActionProps;
 
+export type SequenceAction = {
+  type: "sequence";
+  actions: Action[];
+} & ActionProps;
+
 export type Action =
   | CopyAction
   | OpenAction
   | RunAction
   | ExitAction
   | EditAction
-  | ReloadAction;
+  | ReloadAction
+  | ConfigAction
+  | SequenceAction;
@@ -1,29 +1,47 @@
 export type Manifest = {
   title: string;
   description: string;
+  persistent?: boolean;
+  timeout?: number;
+  permissions?: Permissions;
   preferences?: readonly Input[];
   commands: readonly Command[];
 };
 
+export type Permissions = {
+  network?: boolean;
+  read?: readonly string[];
+  write?: readonly string[];
+  env?: readonly string[];
+};
+
 export type Command = {
   name: string;
   hidden?: boolean;
   title: string;
   params?: readonly Input[];
-  mode: "filter" | "search" | "detail" | "tty" | "silent";
+  mode: "filter" | "search" | "detail" | "grid" | "tty" | "silent";
+  stream?: boolean;
+  timeout?: number;
+  cache?: number;
+  debounce?: number;
 };
 
 export type Input = {
   name: string;
   title: string;
-  type: "string" | "number" | "boolean";
+  type: "string" | "number" | "boolean" | "select" | "secret";
   optional?: boolean;
+  options?: readonly string[];
+  optionsCommand?: string;
 };
 
 type InputMap = {
   string: string;
   number: number;
   boolean: boolean;
+  select: string;
+  secret: string;
 };
 
 type CommandName<M extends Manifest> = M["commands"][number]["name"];
@@ -57,6 +75,7 @@ export type Payload<M extends Manifest> = {
     & {
       command: N;
       cwd: string;
+      cursor?: string;
       preferences: {
         [K in PreferenceName<M>]: PreferenceByName<M, K>["optional"] extends
           true ? InputMap[PreferenceByName<M, K>["type"]] | undefined
@@ -1,3 +1,3 @@
-export type { List, Detail, ListItem } from "./page.ts";
+export type { List, Detail, ListItem, Accessory, Metadata, Grid, GridItem, Error } from "./page.ts";
 export type { Manifest, Payload } from "./manifest.ts";
 export type { Action } from "./action.ts";
@@ -6,18 +6,57 @@ export type List = {
   showDetail?: boolean;
   autoRefreshSeconds?: number;
   emptyText?: string;
+  nextCursor?: string;
+};
+
+export type Grid = {
+  items?: GridItem[];
+  columns?: number;
+  actions?: Action[];
+  emptyText?: string;
+};
+
+export type GridItem = {
+  id?: string;
+  title: string;
+  content: { text: string; } | { emoji: string; } | { image: string; };
+  actions?: Action[];
 };
 
 export type Detail = {
   text?: string;
   markdown?: string;
+  metadata?: Metadata[];
+  actions?: Action[];
+};
+
+export type Accessory = {
+  text?: string;
+  icon?: string;
+  color?: string;
+  tooltip?: string;
+  date?: string;
+};
+
+export type Metadata = {
+  label: string;
+  text?: string;
+  url?: string;
+  tags?: { text: string; color?: string; }[];
+};
+
+export type Error = {
+  title: string;
+  message?: string;
+  hint?: string;
   actions?: Action[];
 };
 
 export type ListItem = {
   title: string;
   subtitle?: string;
-  accessories?: string[];
-  detail?: { text: string; } | { markdown: string; }
+  section?: string;
+  accessories?: (string | Accessory)[];
+  detail?: ({ text: string; } | { markdown: string; } | {}) & { metadata?: Metadata[]; };
   actions?: Action[];
 };
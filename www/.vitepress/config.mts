@@ -126,10 +126,18 @@ export default defineConfig({
                                 text: "Detail",
                                 link: "/docs/reference/schemas/detail",
                             },
+                            {
+                                text: "Grid",
+                                link: "/docs/reference/schemas/grid",
+                            },
                             {
                                 text: "Action",
                                 link: "/docs/reference/schemas/action",
                             },
+                            {
+                                text: "Error",
+                                link: "/docs/reference/schemas/error",
+                            },
                         ],
                     },
                     {